@@ -3,6 +3,7 @@ package main
 import (
 	"app/internal/application"
 	"fmt"
+	"os"
 
 	"github.com/go-sql-driver/mysql"
 )
@@ -22,7 +23,10 @@ func main() {
 			DBName:    "storage_api_db",
 			ParseTime: true,
 		},
-		Address: "127.0.0.1:8080",
+		Address:       "127.0.0.1:8080",
+		CursorSecret:  os.Getenv("CURSOR_SECRET"),
+		AdminToken:    os.Getenv("ADMIN_TOKEN"),
+		GatewaySecret: os.Getenv("GATEWAY_SECRET"),
 	}
 	app := application.NewDefault(cfg)
 	// - run
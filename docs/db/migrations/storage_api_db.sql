@@ -5,6 +5,15 @@ CREATE DATABASE `storage_api_db`;
 
 USE `storage_api_db`;
 
+CREATE TABLE `warehouses` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `code` varchar(64) NOT NULL,
+  `name` varchar(255) NOT NULL,
+  `address` varchar(255) NOT NULL DEFAULT '',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_warehouses_code` (`code`)
+);
+
 CREATE TABLE `products` (
   `id` int NOT NULL AUTO_INCREMENT,
   `name` varchar(255) NOT NULL,
@@ -13,5 +22,96 @@ CREATE TABLE `products` (
   `is_published` boolean NOT NULL,
   `expiration` date NOT NULL,
   `price` decimal(10, 2) NOT NULL,
-  PRIMARY KEY (`id`)
+  `warehouse_id` int NULL DEFAULT NULL,
+  `deleted_at` datetime NULL DEFAULT NULL,
+  `version` int NOT NULL DEFAULT 1,
+  `live_code_value` varchar(255) GENERATED ALWAYS AS (IF(`deleted_at` IS NULL, `code_value`, NULL)) VIRTUAL,
+  PRIMARY KEY (`id`),
+  KEY `idx_products_name` (`name`),
+  KEY `idx_products_code_value_all` (`code_value`),
+  UNIQUE KEY `idx_products_code_value` (`live_code_value`),
+  KEY `idx_products_deleted_at` (`deleted_at`),
+  KEY `idx_products_expiration` (`expiration`, `is_published`),
+  FULLTEXT KEY `idx_products_name_fulltext` (`name`),
+  CONSTRAINT `chk_products_quantity` CHECK (`quantity` >= 0),
+  CONSTRAINT `fk_products_warehouse_id` FOREIGN KEY (`warehouse_id`) REFERENCES `warehouses` (`id`)
+);
+
+CREATE TABLE `stock_movements` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `product_id` int NOT NULL,
+  `kind` varchar(16) NOT NULL,
+  `delta` int NOT NULL,
+  `reason` varchar(32) NOT NULL,
+  `reference` varchar(255) NOT NULL DEFAULT '',
+  `balance` int NOT NULL,
+  `actor` varchar(255) NOT NULL,
+  `created_at` datetime(6) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_stock_movements_product_id` (`product_id`, `id`),
+  CONSTRAINT `chk_stock_movements_balance` CHECK (`balance` >= 0)
+);
+
+CREATE TABLE `reservations` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `product_id` int NOT NULL,
+  `quantity` int NOT NULL,
+  `status` varchar(16) NOT NULL,
+  `reference` varchar(255) NOT NULL DEFAULT '',
+  `actor` varchar(255) NOT NULL,
+  `created_at` datetime(6) NOT NULL,
+  `expires_at` datetime(6) NOT NULL,
+  `resolved_at` datetime(6) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_reservations_product_id` (`product_id`, `status`, `expires_at`),
+  KEY `idx_reservations_status` (`status`, `expires_at`),
+  CONSTRAINT `fk_reservations_product_id` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `price_changes` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `product_id` int NOT NULL,
+  `price` decimal(10, 2) NOT NULL,
+  `effective_at` datetime(6) NOT NULL,
+  `applied_at` datetime(6) NULL DEFAULT NULL,
+  `actor` varchar(255) NOT NULL,
+  `created_at` datetime(6) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_price_changes_product_id` (`product_id`, `effective_at`),
+  KEY `idx_price_changes_pending` (`applied_at`, `effective_at`),
+  CONSTRAINT `fk_price_changes_product_id` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `product_prices` (
+  `product_id` int NOT NULL,
+  `currency` char(3) NOT NULL,
+  `price` decimal(10, 2) NOT NULL,
+  `actor` varchar(255) NOT NULL,
+  `updated_at` datetime(6) NOT NULL,
+  PRIMARY KEY (`product_id`, `currency`),
+  KEY `idx_product_prices_currency` (`currency`),
+  CONSTRAINT `fk_product_prices_product_id` FOREIGN KEY (`product_id`) REFERENCES `products` (`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE `exchange_rates` (
+  `currency` char(3) NOT NULL,
+  `rate` decimal(30, 10) NOT NULL,
+  `actor` varchar(255) NOT NULL,
+  `updated_at` datetime(6) NOT NULL,
+  PRIMARY KEY (`currency`),
+  CONSTRAINT `chk_exchange_rates_rate` CHECK (`rate` > 0)
+);
+
+CREATE TABLE `product_audits` (
+  `id` int NOT NULL AUTO_INCREMENT,
+  `product_id` int NOT NULL,
+  `version` int NOT NULL,
+  `operation` varchar(16) NOT NULL,
+  `actor` varchar(255) NOT NULL,
+  `changed_at` datetime(6) NOT NULL,
+  `before` json NULL DEFAULT NULL,
+  `after` json NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_product_audits_product_id` (`product_id`, `id`),
+  KEY `idx_product_audits_changed_at` (`changed_at`)
 );
\ No newline at end of file
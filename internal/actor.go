@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	// ActorAnonymous is the actor of the changes made by an unknown caller
+	ActorAnonymous = "anonymous"
+	// ActorPriceScheduler is the actor of the scheduled price changes applied by the background job
+	ActorPriceScheduler = "price scheduler"
+	// ActorExpiryJob is the actor of the expired products unpublished by the background job
+	ActorExpiryJob = "expiry job"
+)
+
+// IsReservedActor reports whether name is one of the actors of the application itself, which no caller may take
+func IsReservedActor(name string) bool {
+	for _, reserved := range []string{ActorAnonymous, ActorPriceScheduler, ActorExpiryJob} {
+		if strings.EqualFold(name, reserved) {
+			return true
+		}
+	}
+	return false
+}
+
+// actorKey is the key of the name of the actor stored in a context
+type actorKey struct{}
+
+// WithActor returns a copy of ctx naming who makes the changes done through it, such as the caller of a request or a
+// background job
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorOf returns the name of who makes the changes done through ctx, empty if it is unknown
+func ActorOf(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
@@ -1,10 +1,17 @@
 package application
 
 import (
+	"app/internal"
 	"app/internal/handler"
 	"app/internal/repository"
+	"app/platform/web/auth"
+	"app/platform/web/cursor"
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -17,24 +24,75 @@ type ConfigDefault struct {
 	Database mysql.Config
 	// Address is the address of the application
 	Address string
+	// CursorSecret is the secret used to sign the listing cursors, a random one is generated if empty
+	CursorSecret string
+	// BatchMaxAffected is the maximum number of products a batch update or delete may affect
+	BatchMaxAffected int
+	// QueryTimeout is the maximum duration of a database query, a negative value disables it
+	QueryTimeout time.Duration
+	// AdminToken is the bearer token that grants the admin role, if empty nobody is an admin
+	AdminToken string
+	// GatewaySecret is the secret the trusted gateway sends to name the callers, if empty only admins can name themselves
+	GatewaySecret string
+	// ReservationSweepInterval is the interval between the releases of the expired reservations, a negative value disables them
+	ReservationSweepInterval time.Duration
+	// PriceApplyInterval is the interval between the applications of the scheduled price changes due, a negative value disables them
+	PriceApplyInterval time.Duration
+	// ExpiryInterval is the interval between the unpublishing of the expired products, a negative value disables it
+	ExpiryInterval time.Duration
 }
 
 // NewDefault returns a new default application
 func NewDefault(cfg *ConfigDefault) *Default {
 	// default
 	cfgDefault := &ConfigDefault{
-		Address: ":8080",
+		Address:                  ":8080",
+		BatchMaxAffected:         1000,
+		QueryTimeout:             5 * time.Second,
+		ReservationSweepInterval: 30 * time.Second,
+		PriceApplyInterval:       time.Minute,
+		ExpiryInterval:           time.Hour,
 	}
 	if cfg != nil {
 		cfgDefault.Database = cfg.Database
 		if cfg.Address != "" {
 			cfgDefault.Address = cfg.Address
 		}
+		cfgDefault.CursorSecret = cfg.CursorSecret
+		if cfg.BatchMaxAffected != 0 {
+			cfgDefault.BatchMaxAffected = cfg.BatchMaxAffected
+		}
+		if cfg.QueryTimeout != 0 {
+			cfgDefault.QueryTimeout = cfg.QueryTimeout
+		}
+		cfgDefault.AdminToken = cfg.AdminToken
+		cfgDefault.GatewaySecret = cfg.GatewaySecret
+		if cfg.ReservationSweepInterval != 0 {
+			cfgDefault.ReservationSweepInterval = cfg.ReservationSweepInterval
+		}
+		if cfg.PriceApplyInterval != 0 {
+			cfgDefault.PriceApplyInterval = cfg.PriceApplyInterval
+		}
+		if cfg.ExpiryInterval != 0 {
+			cfgDefault.ExpiryInterval = cfg.ExpiryInterval
+		}
 	}
 
+	// - updates report the rows matched rather than the rows changed, so an update that changes nothing
+	// is not mistaken for a missing product
+	cfgDefault.Database.ClientFoundRows = true
+
 	return &Default{
-		cfgDb: cfgDefault.Database,
-		addr:  cfgDefault.Address,
+		cfgDb:                    cfgDefault.Database,
+		addr:                     cfgDefault.Address,
+		cursorSecret:             []byte(cfgDefault.CursorSecret),
+		batchMaxAffected:         cfgDefault.BatchMaxAffected,
+		queryTimeout:             cfgDefault.QueryTimeout,
+		adminToken:               cfgDefault.AdminToken,
+		gatewaySecret:            cfgDefault.GatewaySecret,
+		reservationSweepInterval: cfgDefault.ReservationSweepInterval,
+		priceApplyInterval:       cfgDefault.PriceApplyInterval,
+		expiryInterval:           cfgDefault.ExpiryInterval,
 	}
 }
 
@@ -44,6 +102,22 @@ type Default struct {
 	cfgDb mysql.Config
 	// addr is the address of the application
 	addr string
+	// cursorSecret is the secret used to sign the listing cursors
+	cursorSecret []byte
+	// batchMaxAffected is the maximum number of products a batch update or delete may affect
+	batchMaxAffected int
+	// queryTimeout is the maximum duration of a database query
+	queryTimeout time.Duration
+	// adminToken is the bearer token that grants the admin role
+	adminToken string
+	// gatewaySecret is the secret the trusted gateway sends to name the callers
+	gatewaySecret string
+	// reservationSweepInterval is the interval between the releases of the expired reservations
+	reservationSweepInterval time.Duration
+	// priceApplyInterval is the interval between the applications of the scheduled price changes due
+	priceApplyInterval time.Duration
+	// expiryInterval is the interval between the unpublishing of the expired products
+	expiryInterval time.Duration
 }
 
 // Run runs the default application
@@ -61,26 +135,163 @@ func (d *Default) Run() (err error) {
 	}
 	
 	// - repository: products
-	rp := repository.NewProductsMySQL(db)
+	rp := repository.NewProductsMySQL(db, d.queryTimeout)
+	// - repository: warehouses
+	rw := repository.NewWarehousesMySQL(db, d.queryTimeout)
+	// - repository: stock movements
+	rm := repository.NewStockMovementsMySQL(db, d.queryTimeout)
+	// - repository: reservations
+	rr := repository.NewReservationsMySQL(db, d.queryTimeout)
+	// - repository: price changes
+	rc := repository.NewPriceChangesMySQL(db, d.queryTimeout)
+	// - repository: product prices in other currencies
+	rl := repository.NewProductPricesMySQL(db, d.queryTimeout)
+	// - repository: exchange rates
+	rx := repository.NewExchangeRatesMySQL(db, d.queryTimeout)
 	
+	// - cursor: signer
+	// (without a configured secret, cursors are only valid until the application restarts)
+	if len(d.cursorSecret) == 0 {
+		d.cursorSecret = make([]byte, 32)
+		if _, err = rand.Read(d.cursorSecret); err != nil {
+			return
+		}
+	}
+	cs := cursor.NewSigner(d.cursorSecret)
+
 	// - handler: products
-	hp := handler.NewProductsDefault(rp)
+	hp := handler.NewProductsDefault(rp, rc, rl, rx, cs, d.batchMaxAffected)
+	// - handler: warehouses
+	hw := handler.NewWarehousesDefault(rw)
+	// - handler: stock movements
+	hm := handler.NewStockMovementsDefault(rm, rp)
+	// - handler: reservations
+	hr := handler.NewReservationsDefault(rr)
+	// - handler: exchange rates
+	hx := handler.NewExchangeRatesDefault(rx)
+
+	// - jobs: stopped when the application stops
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// - jobs: release the expired reservations
+	go runJob(ctx, "reservation sweeper", d.reservationSweepInterval, func(ctx context.Context) error {
+		n, err := rr.Expire(ctx, time.Now())
+		if n > 0 {
+			log.Printf("job reservation sweeper: %d reservations expired", n)
+		}
+		return err
+	})
+	// - jobs: apply the scheduled price changes due, audited as made by the scheduler
+	go runJob(internal.WithActor(ctx, internal.ActorPriceScheduler), "price scheduler", d.priceApplyInterval, func(ctx context.Context) error {
+		n, err := rc.Apply(ctx, time.Now())
+		if n > 0 {
+			log.Printf("job price scheduler: %d product prices changed", n)
+		}
+		return err
+	})
+	// - jobs: unpublish the expired products, audited as expired by the expiry job
+	// (batch after batch, so a backlog of expired products does not wait for the next ticks)
+	go runJob(internal.WithActor(ctx, internal.ActorExpiryJob), "expiry", d.expiryInterval, func(ctx context.Context) (err error) {
+		total := 0
+		for {
+			var n int
+			n, err = rp.UnpublishExpired(ctx, time.Now())
+			total += n
+			if err != nil || n < repository.ExpireBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+		if total > 0 {
+			log.Printf("job expiry: %d expired products unpublished", total)
+		}
+		return
+	})
 
 	// - router: chi
 	rt := chi.NewRouter()
 	// - router: middlewares
 	rt.Use(middleware.Logger)
 	rt.Use(middleware.Recoverer)
+	rt.Use(auth.Middleware(d.adminToken, d.gatewaySecret))
+	rt.Use(handler.Actor)
 	// - router: routes
 	rt.Route("/products", func(r chi.Router) {
 		// - GET /products
-		r.Get("/", hp.GetOne())
+		r.Get("/", hp.GetAll())
+		// - GET /products/search
+		r.Get("/search", hp.Search())
+		// - GET /products/suggest
+		r.Get("/suggest", hp.Suggest())
+		// - GET /products/export
+		r.Get("/export", hp.Export())
+		// - GET /products/trash
+		r.Get("/trash", hp.Trash())
+		// - GET /products/expiring
+		r.Get("/expiring", hp.Expiring())
+		// - GET /products/{id}
+		r.Get("/{id}", hp.GetOne())
+		// - GET /products/{id}/history
+		r.Get("/{id}/history", hp.History())
 		// - POST /products
 		r.Post("/", hp.Create())
-		// - PUT /products/{id}
+		// - POST /products/batch
+		r.Post("/batch", hp.CreateBatch())
+		// - POST /products/import
+		r.Post("/import", hp.Import())
+		// - PATCH /products/batch
+		r.Patch("/batch", hp.UpdateBatch())
+		// - DELETE /products/batch
+		r.Delete("/batch", hp.DeleteBatch())
+		// - PATCH /products/{id}
 		r.Patch("/{id}", hp.Update())
 		// - DELETE /products/{id}
 		r.Delete("/{id}", hp.Delete())
+		// - POST /products/{id}/restore
+		r.Post("/{id}/restore", hp.Restore())
+		// - POST /products/{id}/quantity/adjust
+		r.Post("/{id}/quantity/adjust", hp.AdjustQuantity())
+		// - GET /products/{id}/prices
+		r.Get("/{id}/prices", hp.Prices())
+		// - POST /products/{id}/prices
+		r.Post("/{id}/prices", hp.SchedulePrice())
+		// - DELETE /products/{id}/prices/{price_id}
+		r.Delete("/{id}/prices/{price_id}", hp.CancelPrice())
+		// - GET /products/{id}/currencies
+		r.Get("/{id}/currencies", hp.Currencies())
+		// - PUT /products/{id}/currencies/{currency}
+		r.Put("/{id}/currencies/{currency}", hp.SetCurrency())
+		// - DELETE /products/{id}/currencies/{currency}
+		r.Delete("/{id}/currencies/{currency}", hp.DeleteCurrency())
+		// - GET /products/{id}/reservations
+		r.Get("/{id}/reservations", hr.GetAll())
+		// - POST /products/{id}/reservations
+		r.Post("/{id}/reservations", hr.Create())
+		// - POST /products/{id}/reservations/{reservation_id}/confirm
+		r.Post("/{id}/reservations/{reservation_id}/confirm", hr.Confirm())
+		// - POST /products/{id}/reservations/{reservation_id}/cancel
+		r.Post("/{id}/reservations/{reservation_id}/cancel", hr.Cancel())
+		// - GET /products/{id}/movements
+		r.Get("/{id}/movements", hm.GetAll())
+		// - POST /products/{id}/movements
+		r.Post("/{id}/movements", hm.Create())
+	})
+	rt.Route("/warehouses", func(r chi.Router) {
+		// - GET /warehouses
+		r.Get("/", hw.GetAll())
+		// - GET /warehouses/{id}
+		r.Get("/{id}", hw.GetOne())
+		// - POST /warehouses
+		r.Post("/", hw.Create())
+		// - PATCH /warehouses/{id}
+		r.Patch("/{id}", hw.Update())
+		// - DELETE /warehouses/{id}
+		r.Delete("/{id}", hw.Delete())
+	})
+	rt.Route("/exchange-rates", func(r chi.Router) {
+		// - GET /exchange-rates
+		r.Get("/", hx.GetAll())
+		// - PUT /exchange-rates (admin only)
+		r.With(auth.RequireAdmin).Put("/", hx.Replace())
 	})
 
 	// run
@@ -0,0 +1,27 @@
+package application
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runJob calls fn every interval until ctx is done
+// - errors are logged and the job carries on at the next tick, an interval <= 0 disables the job
+func runJob(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fn(ctx); err != nil {
+				log.Printf("job %s: %v", name, err)
+			}
+		}
+	}
+}
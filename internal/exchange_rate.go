@@ -0,0 +1,21 @@
+package internal
+
+import (
+	"math/big"
+	"time"
+)
+
+// ExchangeRateScale is the number of decimal digits of the exchange rates stored
+const ExchangeRateScale = 10
+
+// ExchangeRate is an struct that represents the rate to convert amounts of DefaultCurrency into another currency
+type ExchangeRate struct {
+	// Currency is the ISO 4217 code of the currency converted into
+	Currency string
+	// Rate is the amount of the currency worth one unit of DefaultCurrency, with at most ExchangeRateScale decimal digits
+	Rate *big.Rat
+	// Actor is the name of who uploaded the rate
+	Actor string
+	// UpdatedAt is the time the rate was uploaded
+	UpdatedAt time.Time
+}
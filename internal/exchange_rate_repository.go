@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrExchangeRateNotFound is an error that will be returned when there is no exchange rate into a currency
+	ErrExchangeRateNotFound = errors.New("repository: exchange rate not found")
+)
+
+// RepositoryExchangeRates is an interface that represents a repository of the exchange rates from DefaultCurrency
+// - the rates are maintained locally, they are uploaded as a whole set rather than fetched from a provider
+// - every method stops when ctx is done, returning context.DeadlineExceeded when its deadline expires
+type RepositoryExchangeRates interface {
+	// GetOne returns the exchange rate into a currency
+	GetOne(ctx context.Context, currency string) (r ExchangeRate, err error)
+	// GetAll returns every exchange rate, ordered by currency
+	GetAll(ctx context.Context) (r []ExchangeRate, err error)
+	// Replace replaces the set of exchange rates in a single transaction, the currencies not in r are removed
+	Replace(ctx context.Context, r []ExchangeRate) (err error)
+}
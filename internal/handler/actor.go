@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/web/auth"
+	"app/platform/web/response"
+	"net/http"
+)
+
+// Actor is a middleware that names the caller identified by auth.Middleware as the actor of the changes of the request
+// - the names reserved to the application, such as the ones of its background jobs, are rejected
+func Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := auth.User(r.Context())
+		if internal.IsReservedActor(user) {
+			response.Errorf(w, http.StatusBadRequest, "invalid user %q, the name is reserved", user)
+			return
+		}
+		ctx := internal.WithActor(r.Context(), user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/web/request"
+	"app/platform/web/response"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NewExchangeRatesDefault returns a new instance of ExchangeRatesDefault
+func NewExchangeRatesDefault(rx internal.RepositoryExchangeRates) *ExchangeRatesDefault {
+	return &ExchangeRatesDefault{
+		rx: rx,
+	}
+}
+
+// ExchangeRatesDefault is a struct that represents the default exchange rate handler
+type ExchangeRatesDefault struct {
+	// rx is the exchange rate repository
+	rx internal.RepositoryExchangeRates
+}
+
+// ExchangeRateJSON is a struct that represents an exchange rate in JSON
+type ExchangeRateJSON struct {
+	Currency  string `json:"currency"`
+	Rate      string `json:"rate"`
+	Actor     string `json:"actor"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// formatRate returns an exchange rate in decimal notation, without trailing zeros
+func formatRate(rate *big.Rat) string {
+	s := rate.FloatString(internal.ExchangeRateScale)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// serializeExchangeRate returns the JSON representation of an exchange rate
+func serializeExchangeRate(er internal.ExchangeRate) ExchangeRateJSON {
+	return ExchangeRateJSON{
+		Currency:  er.Currency,
+		Rate:      formatRate(er.Rate),
+		Actor:     er.Actor,
+		UpdatedAt: er.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// GetAll returns the exchange rates from the default currency
+func (h *ExchangeRatesDefault) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// process
+		ers, err := h.rx.GetAll(r.Context())
+		if err != nil {
+			repositoryError(w, err)
+			return
+		}
+
+		// response
+		// - serialize
+		data := make([]ExchangeRateJSON, 0, len(ers))
+		for _, er := range ers {
+			data = append(data, serializeExchangeRate(er))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{"message": "exchange rates found", "base": internal.DefaultCurrency, "data": data})
+	}
+}
+
+// RequestBodyExchangeRates is a struct that represents the request body of a set of exchange rates to upload
+// - rates maps the currencies to the amount of them worth one unit of base, as strings or numbers
+type RequestBodyExchangeRates struct {
+	Base  string                 `json:"base"`
+	Rates map[string]json.Number `json:"rates"`
+}
+
+// parse returns the exchange rates of the body, ordered by currency, or the message of the first invalid one
+func (b RequestBodyExchangeRates) parse() (ers []internal.ExchangeRate, msg string) {
+	if b.Base != "" && b.Base != internal.DefaultCurrency {
+		msg = fmt.Sprintf("invalid base, the rates must be from %s", internal.DefaultCurrency)
+		return
+	}
+	// - a rate is scaled to an integer to check it has no more decimal digits than the ones stored
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(internal.ExchangeRateScale), nil))
+	for currency, n := range b.Rates {
+		if !internal.ValidCurrency(currency) || currency == internal.DefaultCurrency {
+			msg = fmt.Sprintf("invalid currency %s, must be an ISO 4217 code other than %s", currency, internal.DefaultCurrency)
+			return
+		}
+		rate, ok := new(big.Rat).SetString(n.String())
+		if !ok || rate.Sign() <= 0 || !new(big.Rat).Mul(rate, scale).IsInt() {
+			msg = fmt.Sprintf("invalid rate of %s, must be a positive decimal number with at most %d decimals", currency, internal.ExchangeRateScale)
+			return
+		}
+		ers = append(ers, internal.ExchangeRate{Currency: currency, Rate: rate})
+	}
+	sort.Slice(ers, func(i, j int) bool { return ers[i].Currency < ers[j].Currency })
+	return
+}
+
+// Replace uploads a set of exchange rates, replacing the whole previous set
+// - the currencies not in the set can no longer be converted into
+func (h *ExchangeRatesDefault) Replace() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body RequestBodyExchangeRates
+		if err := request.JSON(r, &body); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		ers, msg := body.parse()
+		if msg != "" {
+			response.Error(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		// process
+		if err := h.rx.Replace(r.Context(), ers); err != nil {
+			repositoryError(w, err)
+			return
+		}
+
+		// response
+		// - serialize
+		data := make([]ExchangeRateJSON, 0, len(ers))
+		for _, er := range ers {
+			data = append(data, serializeExchangeRate(er))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{"message": "exchange rates uploaded", "base": internal.DefaultCurrency, "data": data})
+	}
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/web/request"
+	"app/platform/web/response"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// invalidCurrencyMessage is the error message of a currency a product can not be priced in explicitly
+var invalidCurrencyMessage = fmt.Sprintf("invalid currency, must be an ISO 4217 code other than %s", internal.DefaultCurrency)
+
+// queryCurrency returns the currency of a query parameter of the request, internal.DefaultCurrency if it is not set
+func queryCurrency(r *http.Request, key string) (currency string, err error) {
+	currency = strings.ToUpper(r.URL.Query().Get(key))
+	switch {
+	case currency == "":
+		currency = internal.DefaultCurrency
+	case !internal.ValidCurrency(currency):
+		err = fmt.Errorf("invalid currency %q", currency)
+	}
+	return
+}
+
+// localizePrices sets the price of the products to the one in currency: the price set explicitly in it,
+// or else their price converted with the exchange rate into it
+// - internal.ErrExchangeRateNotFound is returned if a product has to be converted and there is no rate into currency
+func (h *ProductsDefault) localizePrices(ctx context.Context, currency string, ps ...*internal.Product) (err error) {
+	if currency == internal.DefaultCurrency {
+		return
+	}
+	ids := make([]int, len(ps))
+	for i, p := range ps {
+		ids[i] = p.ID
+	}
+	prices, err := h.rl.In(ctx, ids, currency)
+	if err != nil {
+		return
+	}
+	var rate *internal.ExchangeRate
+	for _, p := range ps {
+		if price, ok := prices[p.ID]; ok {
+			p.Price = price
+			continue
+		}
+		if rate == nil {
+			var er internal.ExchangeRate
+			if er, err = h.rx.GetOne(ctx, currency); err != nil {
+				return
+			}
+			rate = &er
+		}
+		if p.Price, err = p.Price.Convert(currency, rate.Rate, internal.PriceScale, internal.RoundHalfEven); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ProductPriceJSON is a struct that represents the price of a product set explicitly in a currency in JSON
+type ProductPriceJSON struct {
+	Currency  string    `json:"currency"`
+	Price     MoneyJSON `json:"price"`
+	Actor     string    `json:"actor"`
+	UpdatedAt string    `json:"updated_at"`
+}
+
+// serializeProductPrice returns the JSON representation of the price of a product set explicitly in a currency
+func serializeProductPrice(p internal.ProductPrice, numeric bool) ProductPriceJSON {
+	return ProductPriceJSON{
+		Currency:  p.Price.Currency(),
+		Price:     MoneyJSON{Money: p.Price, Numeric: numeric},
+		Actor:     p.Actor,
+		UpdatedAt: p.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// Currencies returns the prices of a product set explicitly in other currencies
+// - in the other currencies with an exchange rate, the product is priced by converting its price
+func (h *ProductsDefault) Currencies() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		_, err = h.rp.GetOne(r.Context(), id)
+		var pps []internal.ProductPrice
+		if err == nil {
+			pps, err = h.rl.GetAll(r.Context(), id)
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrProductNotFound):
+				response.Error(w, http.StatusNotFound, "product not found")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		// - serialize
+		data := make([]ProductPriceJSON, 0, len(pps))
+		for _, pp := range pps {
+			data = append(data, serializeProductPrice(pp, numericMoney(r)))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{"message": "product prices found", "data": data})
+	}
+}
+
+// RequestBodyProductPrice is a struct that represents the request body of the price of a product in a currency
+type RequestBodyProductPrice struct {
+	Price internal.Money `json:"price"`
+}
+
+// SetCurrency sets the price of a product in a currency, so it is no longer converted into it
+func (h *ProductsDefault) SetCurrency() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		currency := strings.ToUpper(chi.URLParam(r, "currency"))
+		if !internal.ValidCurrency(currency) || currency == internal.DefaultCurrency {
+			response.Error(w, http.StatusBadRequest, invalidCurrencyMessage)
+			return
+		}
+		var body RequestBodyProductPrice
+		if err := request.JSON(r, &body); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if !normalizePrice(&body.Price) {
+			response.Error(w, http.StatusBadRequest, invalidPriceMessage)
+			return
+		}
+
+		// process
+		pp := internal.ProductPrice{
+			ProductID: id,
+			Price:     internal.NewMoney(body.Price.Units(), body.Price.Scale(), currency),
+		}
+		if err := h.rl.Set(r.Context(), &pp); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrProductNotFound):
+				response.Error(w, http.StatusNotFound, "product not found")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		// - serialize
+		data := serializeProductPrice(pp, numericMoney(r))
+		response.JSON(w, http.StatusOK, map[string]any{"message": "product price set", "data": data})
+	}
+}
+
+// DeleteCurrency deletes the price of a product in a currency, so it is converted into it again
+func (h *ProductsDefault) DeleteCurrency() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		currency := strings.ToUpper(chi.URLParam(r, "currency"))
+		if !internal.ValidCurrency(currency) || currency == internal.DefaultCurrency {
+			response.Error(w, http.StatusBadRequest, invalidCurrencyMessage)
+			return
+		}
+
+		// process
+		if err := h.rl.Delete(r.Context(), id, currency); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrProductPriceNotFound):
+				response.Error(w, http.StatusNotFound, "product price not found")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{"message": "product price deleted", "data": currency})
+	}
+}
@@ -2,20 +2,34 @@ package handler
 
 import (
 	"app/internal"
+	"app/platform/filter"
+	"app/platform/trie"
+	"app/platform/web/auth"
+	"app/platform/web/cursor"
 	"app/platform/web/request"
 	"app/platform/web/response"
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 )
 
 // NewProductsDefault returns a new instance of ProductsDefault
-func NewProductsDefault(rp internal.RepositoryProducts) *ProductsDefault {
+func NewProductsDefault(rp internal.RepositoryProducts, rc internal.RepositoryPriceChanges, rl internal.RepositoryProductPrices, rx internal.RepositoryExchangeRates, cs *cursor.Signer, maxAffected int) *ProductsDefault {
 	return &ProductsDefault{
-		rp: rp,
+		rp:          rp,
+		rc:          rc,
+		rl:          rl,
+		rx:          rx,
+		cs:          cs,
+		maxAffected: maxAffected,
+		names:       trie.New(maxSuggestCacheSize),
+		codes:       trie.New(maxSuggestCacheSize),
 	}
 }
 
@@ -23,20 +37,542 @@ func NewProductsDefault(rp internal.RepositoryProducts) *ProductsDefault {
 type ProductsDefault struct {
 	// rp is the product repository
 	rp internal.RepositoryProducts
+	// rc is the repository of the scheduled price changes
+	rc internal.RepositoryPriceChanges
+	// rl is the repository of the prices of the products in other currencies
+	rl internal.RepositoryProductPrices
+	// rx is the repository of the exchange rates
+	rx internal.RepositoryExchangeRates
+	// cs is the signer of the listing cursors
+	cs *cursor.Signer
+	// maxAffected is the maximum number of products a batch update or delete may affect
+	maxAffected int
+	// names is the cache of the product names suggested by prefix
+	names *trie.Trie
+	// codes is the cache of the product code values suggested by prefix
+	codes *trie.Trie
 }
 
 // ProductJSON is a struct that represents a product in JSON
 type ProductJSON struct {
-	ID          int     `json:"id"`
-	Name        string  `json:"name"`
-	Quantity    int     `json:"quantity"`
-	CodeValue   string  `json:"code_value"`
-	IsPublished bool    `json:"is_published"`
-	Expiration  string  `json:"expiration"`
-	Price       float64 `json:"price"`
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Quantity    int       `json:"quantity"`
+	CodeValue   string    `json:"code_value"`
+	IsPublished bool      `json:"is_published"`
+	Expiration  string    `json:"expiration"`
+	Price       MoneyJSON `json:"price"`
+	Currency    string    `json:"currency"`
+	WarehouseID *int      `json:"warehouse_id"`
+	Version     int       `json:"version"`
+	DeletedAt   *string   `json:"deleted_at,omitempty"`
+}
+
+// moneyFormatHeader is the header a legacy client sets to "number" to read amounts of money as JSON numbers
+const moneyFormatHeader = "X-Money-Format"
+
+// MoneyJSON is a struct that represents an amount of money in JSON, a string by default so no precision is lost
+type MoneyJSON struct {
+	internal.Money
+	// Numeric encodes the amount as a number instead, with all its decimal digits
+	Numeric bool
+}
+
+// MarshalJSON encodes the amount as a string, or as a number in numeric mode
+func (m MoneyJSON) MarshalJSON() ([]byte, error) {
+	if m.Numeric {
+		return []byte(m.Money.String()), nil
+	}
+	return m.Money.MarshalJSON()
+}
+
+// numericMoney reports whether the client of the request reads amounts of money as JSON numbers
+func numericMoney(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get(moneyFormatHeader), "number")
+}
+
+// normalizePrice rescales an amount to the scale of the prices, reporting whether it can be stored as a price
+// - amounts with fewer decimals are padded, so "10.5" is stored and returned as "10.50"
+func normalizePrice(m *internal.Money) bool {
+	if m.Sign() < 0 || m.Scale() > internal.PriceScale {
+		return false
+	}
+	r, err := m.Rescale(internal.PriceScale, internal.RoundHalfEven)
+	if err != nil {
+		return false
+	}
+	*m = r
+	return true
+}
+
+// invalidPriceMessage is the error message of a price that can not be stored
+var invalidPriceMessage = fmt.Sprintf("invalid price, must be a non-negative amount with at most %d decimals", internal.PriceScale)
+
+// serializeProduct returns the JSON representation of a product, with its price in numeric mode if numeric
+func serializeProduct(p internal.Product, numeric bool) ProductJSON {
+	data := ProductJSON{
+		ID:          p.ID,
+		Name:        p.Name,
+		Quantity:    p.Quantity,
+		CodeValue:   p.CodeValue,
+		IsPublished: p.IsPublished,
+		Expiration:  p.Expiration.Format(time.DateOnly),
+		Price:       MoneyJSON{Money: p.Price, Numeric: numeric},
+		Currency:    p.Price.Currency(),
+		WarehouseID: p.WarehouseID,
+		Version:     p.Version,
+	}
+	if p.DeletedAt != nil {
+		deletedAt := p.DeletedAt.Format(time.RFC3339)
+		data.DeletedAt = &deletedAt
+	}
+	return data
+}
+
+const (
+	// defaultLimit is the default number of products returned per page
+	defaultLimit = 20
+	// maxLimit is the maximum number of products returned per page
+	maxLimit = 100
+)
+
+// PaginationJSON is a struct that represents the pagination of a listing in JSON
+type PaginationJSON struct {
+	// Total is the number of items, left out of the pages after a cursor
+	Total  *int    `json:"total,omitempty"`
+	Limit  int     `json:"limit"`
+	Offset int     `json:"offset"`
+	Next   *string `json:"next"`
+	Prev   *string `json:"prev"`
+	// NextCursor is the token to resume the listing after the last product of the page
+	NextCursor *string `json:"next_cursor"`
+}
+
+// productFields maps the fields of a product that can be filtered and sorted to their types
+var productFields = map[string]filter.Type{
+	"id":           filter.Number,
+	"name":         filter.String,
+	"quantity":     filter.Number,
+	"code_value":   filter.String,
+	"is_published": filter.Bool,
+	"expiration":   filter.Date,
+	"price":        filter.Number,
+}
+
+// cursorJSON is a struct that represents the payload of a listing cursor
+type cursorJSON struct {
+	// Sort is the sort parameter the cursor was issued for
+	Sort string `json:"sort,omitempty"`
+	// AsOf is the as_of parameter the cursor was issued for
+	AsOf string `json:"as_of,omitempty"`
+	// ID is the id of the last product seen
+	ID int `json:"id"`
+	// Values are the sort key values of the last product seen
+	Values []any `json:"values,omitempty"`
+}
+
+// parseSort parses a sort parameter such as "-price,name" into sort criteria
+// - a leading "-" orders the field descending, fields are validated against productFields
+func parseSort(s string) (sorts []internal.ProductSort, err error) {
+	if s == "" {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, term := range strings.Split(s, ",") {
+		term = strings.TrimSpace(term)
+		var sort internal.ProductSort
+		switch {
+		case strings.HasPrefix(term, "-"):
+			sort = internal.ProductSort{Field: term[1:], Desc: true}
+		case strings.HasPrefix(term, "+"):
+			sort = internal.ProductSort{Field: term[1:]}
+		default:
+			sort = internal.ProductSort{Field: term}
+		}
+		if _, ok := productFields[sort.Field]; !ok {
+			err = fmt.Errorf("unknown field %q", sort.Field)
+			return
+		}
+		if seen[sort.Field] {
+			err = fmt.Errorf("duplicated field %q", sort.Field)
+			return
+		}
+		seen[sort.Field] = true
+		sorts = append(sorts, sort)
+	}
+	return
+}
+
+// productFieldValue returns the value of a field of a product as it is stored in a cursor
+func productFieldValue(p internal.Product, field string) any {
+	switch field {
+	case "id":
+		return p.ID
+	case "name":
+		return p.Name
+	case "quantity":
+		return p.Quantity
+	case "code_value":
+		return p.CodeValue
+	case "is_published":
+		return p.IsPublished
+	case "expiration":
+		return p.Expiration.Format(time.DateOnly)
+	case "price":
+		return p.Price.String()
+	}
+	return nil
+}
+
+// pageLink returns the url of the request with the given query parameters replaced
+func pageLink(r *http.Request, params map[string]string) *string {
+	u := *r.URL
+	q := u.Query()
+	for k, v := range params {
+		q.Set(k, v)
+	}
+	u.RawQuery = q.Encode()
+	link := u.RequestURI()
+	return &link
+}
+
+// queryInt returns the integer value of a query parameter, or def if it is not present
+func queryInt(r *http.Request, key string, def int) (v int, err error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		v = def
+		return
+	}
+	v, err = strconv.Atoi(s)
+	return
+}
+
+// queryTime returns the RFC 3339 time value of a query parameter, or nil if it is not present
+func queryTime(r *http.Request, key string) (v *time.Time, err error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return
+	}
+	v = &t
+	return
+}
+
+// conflictMessage returns message followed by the field that caused a repository error, if it is known
+func conflictMessage(message string, err error) string {
+	var fe *internal.FieldError
+	if errors.As(err, &fe) && fe.Field != "" {
+		return fmt.Sprintf("%s, field %s", message, fe.Field)
+	}
+	return message
+}
+
+// repositoryError writes the response of a repository error the handler has no specific response for
+// - a query that ran out of time is reported as a gateway timeout, any other error as an internal error
+func repositoryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		response.Error(w, http.StatusGatewayTimeout, "database timeout")
+	default:
+		response.Error(w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// effectivePrices sets the price of the products to the one of their latest scheduled change due, if it was not applied yet
+// - filters and sorts still see the stored price until the change is applied
+func (h *ProductsDefault) effectivePrices(ctx context.Context, ps ...*internal.Product) (err error) {
+	ids := make([]int, len(ps))
+	for i, p := range ps {
+		ids[i] = p.ID
+	}
+	prices, err := h.rc.Effective(ctx, ids, time.Now())
+	if err != nil {
+		return
+	}
+	for _, p := range ps {
+		if price, ok := prices[p.ID]; ok {
+			p.Price = price
+		}
+	}
+	return
+}
+
+// GetAll returns a page of products
+// - pages are addressed either by offset or, for large catalogs, by the opaque cursor of a previous page
+// - as_of lists the products as they were at that instant
+func (h *ProductsDefault) GetAll() http.HandlerFunc {
+	return h.list(false)
+}
+
+// Trash returns a page of the products in the trash
+func (h *ProductsDefault) Trash() http.HandlerFunc {
+	return h.list(true)
+}
+
+// list returns a handler of a page of the active products, or of the products in the trash
+func (h *ProductsDefault) list(trashed bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		limit, err := queryInt(r, "limit", defaultLimit)
+		if err != nil || limit < 1 || limit > maxLimit {
+			response.Errorf(w, http.StatusBadRequest, "invalid limit, must be between 1 and %d", maxLimit)
+			return
+		}
+		offset, err := queryInt(r, "offset", 0)
+		if err != nil || offset < 0 {
+			response.Error(w, http.StatusBadRequest, "invalid offset")
+			return
+		}
+		q := internal.ProductQuery{Limit: limit, Offset: offset, Trashed: trashed}
+		asOf := r.URL.Query().Get("as_of")
+		q.AsOf, err = queryTime(r, "as_of")
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid as_of, must be an RFC 3339 time")
+			return
+		}
+		if expr := r.URL.Query().Get("filter"); expr != "" {
+			q.Filter, err = filter.Parse(expr, productFields)
+			if err != nil {
+				response.Errorf(w, http.StatusBadRequest, "invalid filter: %s", err)
+				return
+			}
+		}
+		sort := r.URL.Query().Get("sort")
+		q.Sort, err = parseSort(sort)
+		if err != nil {
+			response.Errorf(w, http.StatusBadRequest, "invalid sort: %s", err)
+			return
+		}
+		if token := r.URL.Query().Get("cursor"); token != "" {
+			if r.URL.Query().Has("offset") {
+				response.Error(w, http.StatusBadRequest, "cursor and offset can not be used together")
+				return
+			}
+			var c cursorJSON
+			if err := h.cs.Decode(token, &c); err != nil || c.Sort != sort || c.AsOf != asOf || len(c.Values) != len(q.Sort) {
+				response.Error(w, http.StatusBadRequest, "invalid cursor")
+				return
+			}
+			q.After = &internal.ProductCursor{ID: c.ID, Values: c.Values}
+		}
+
+		// process
+		ps, total, err := h.rp.GetAll(r.Context(), q)
+		if err != nil {
+			repositoryError(w, err)
+			return
+		}
+		// - the cursor holds the stored values the keyset compares against, so it is built
+		// before the scheduled prices already due replace the stored ones
+		pagination := PaginationJSON{Limit: limit, Offset: offset}
+		if q.After == nil {
+			pagination.Total = &total
+		}
+		if len(ps) == limit {
+			last := ps[len(ps)-1]
+			c := cursorJSON{Sort: sort, AsOf: asOf, ID: last.ID}
+			for _, s := range q.Sort {
+				c.Values = append(c.Values, productFieldValue(last, s.Field))
+			}
+			token, err := h.cs.Encode(c)
+			if err != nil {
+				response.Error(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+			pagination.NextCursor = &token
+		}
+		if q.AsOf == nil {
+			pps := make([]*internal.Product, len(ps))
+			for i := range ps {
+				pps[i] = &ps[i]
+			}
+			if err := h.effectivePrices(r.Context(), pps...); err != nil {
+				repositoryError(w, err)
+				return
+			}
+		}
+
+		// response
+		// - serialize
+		data := make([]ProductJSON, 0, len(ps))
+		for _, p := range ps {
+			data = append(data, serializeProduct(p, numericMoney(r)))
+		}
+		// - pagination
+		switch {
+		case q.After != nil:
+			if pagination.NextCursor != nil {
+				pagination.Next = pageLink(r, map[string]string{"limit": strconv.Itoa(limit), "cursor": *pagination.NextCursor})
+			}
+		default:
+			if offset+limit < total {
+				pagination.Next = pageLink(r, map[string]string{"limit": strconv.Itoa(limit), "offset": strconv.Itoa(offset + limit)})
+			}
+			if offset > 0 {
+				pagination.Prev = pageLink(r, map[string]string{"limit": strconv.Itoa(limit), "offset": strconv.Itoa(max(offset-limit, 0))})
+			}
+		}
+		response.JSON(w, http.StatusOK, map[string]any{"message": "products found", "data": data, "pagination": pagination})
+	}
+}
+
+// ProductSearchJSON is a struct that represents a product found by a search in JSON
+type ProductSearchJSON struct {
+	ProductJSON
+	Score float64 `json:"score"`
+}
+
+// Search returns the products matching a full-text search ranked by relevance
+// - mode is either natural (default) or boolean
+func (h *ProductsDefault) Search() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			response.Error(w, http.StatusBadRequest, "invalid q, must not be empty")
+			return
+		}
+		limit, err := queryInt(r, "limit", defaultLimit)
+		if err != nil || limit < 1 || limit > maxLimit {
+			response.Errorf(w, http.StatusBadRequest, "invalid limit, must be between 1 and %d", maxLimit)
+			return
+		}
+		s := internal.ProductSearch{Query: query, Limit: limit}
+		switch r.URL.Query().Get("mode") {
+		case "", "natural":
+		case "boolean":
+			s.Boolean = true
+		default:
+			response.Error(w, http.StatusBadRequest, "invalid mode, must be natural or boolean")
+			return
+		}
+
+		// process
+		res, err := h.rp.Search(r.Context(), s)
+		if err == nil {
+			pps := make([]*internal.Product, len(res))
+			for i := range res {
+				pps[i] = &res[i].Product
+			}
+			err = h.effectivePrices(r.Context(), pps...)
+		}
+		if err != nil {
+			repositoryError(w, err)
+			return
+		}
+
+		// response
+		// - serialize
+		data := make([]ProductSearchJSON, 0, len(res))
+		for _, sr := range res {
+			data = append(data, ProductSearchJSON{ProductJSON: serializeProduct(sr.Product, numericMoney(r)), Score: sr.Score})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{"message": "products found", "data": data})
+	}
+}
+
+const (
+	// defaultSuggestLimit is the default number of suggestions returned per field
+	defaultSuggestLimit = 10
+	// maxSuggestLimit is the maximum number of suggestions returned per field
+	maxSuggestLimit = 50
+	// maxSuggestCacheSize is the maximum number of values cached per field for the suggestions
+	maxSuggestCacheSize = 10000
+)
+
+// suggest returns up to limit values of a field starting with prefix
+// - the cache is used when it is known to hold every value of the prefix, otherwise the repository is queried
+// and its result is cached, marking the prefix complete when fewer values than the limit exist
+func (h *ProductsDefault) suggest(ctx context.Context, cache *trie.Trie, field, prefix string, limit int) (values []string, err error) {
+	if cache.Complete(prefix) {
+		values = cache.Find(prefix, limit)
+		return
+	}
+
+	s, err := h.rp.Suggest(ctx, field, prefix, limit)
+	if err != nil {
+		return
+	}
+	values = make([]string, 0, len(s))
+	fill := make(map[int]string, len(s))
+	for _, ps := range s {
+		fill[ps.ID] = ps.Value
+		if len(values) == 0 || values[len(values)-1] != ps.Value {
+			values = append(values, ps.Value)
+		}
+	}
+	cache.Fill(prefix, fill, len(s) < limit)
+	return
+}
+
+// cacheProduct keeps the suggestion caches warm with the values of a product
+func (h *ProductsDefault) cacheProduct(p internal.Product) {
+	h.names.Set(p.ID, p.Name)
+	h.codes.Set(p.ID, p.CodeValue)
+}
+
+// uncacheProduct removes the values of a product from the suggestion caches
+func (h *ProductsDefault) uncacheProduct(id int) {
+	h.names.Delete(id)
+	h.codes.Delete(id)
+}
+
+// Suggest returns the product names and code values starting with a prefix
+func (h *ProductsDefault) Suggest() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		prefix := r.URL.Query().Get("prefix")
+		if prefix == "" {
+			response.Error(w, http.StatusBadRequest, "invalid prefix, must not be empty")
+			return
+		}
+		limit, err := queryInt(r, "limit", defaultSuggestLimit)
+		if err != nil || limit < 1 || limit > maxSuggestLimit {
+			response.Errorf(w, http.StatusBadRequest, "invalid limit, must be between 1 and %d", maxSuggestLimit)
+			return
+		}
+
+		// process
+		names, err := h.suggest(r.Context(), h.names, "name", prefix, limit)
+		if err != nil {
+			repositoryError(w, err)
+			return
+		}
+		codes, err := h.suggest(r.Context(), h.codes, "code_value", prefix, limit)
+		if err != nil {
+			repositoryError(w, err)
+			return
+		}
+
+		// response
+		data := map[string]any{"names": names, "code_values": codes}
+		response.JSON(w, http.StatusOK, map[string]any{"message": "suggestions found", "data": data})
+	}
+}
+
+// etag returns the entity tag of a version of a product
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatch reports whether the If-Match header of the request matches a version of a product
+func ifMatch(r *http.Request, version int) bool {
+	for _, tag := range strings.Split(r.Header.Get("If-Match"), ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag(version) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetOne returns a product by id
+// - as_of returns the product as it was at that instant, without an ETag as it can not be updated
+// - otherwise the price is the effective one, scheduled changes due are resolved even before they are applied
+// - currency returns the price in that currency, the one set for it or else the effective price converted into it
 func (h *ProductsDefault) GetOne() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
@@ -45,29 +581,51 @@ func (h *ProductsDefault) GetOne() http.HandlerFunc {
 			response.Error(w, http.StatusBadRequest, "invalid id")
 			return
 		}
+		asOf, err := queryTime(r, "as_of")
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid as_of, must be an RFC 3339 time")
+			return
+		}
+		currency, err := queryCurrency(r, "currency")
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid currency, must be an ISO 4217 code")
+			return
+		}
+		if asOf != nil && currency != internal.DefaultCurrency {
+			response.Error(w, http.StatusBadRequest, "currency can not be used together with as_of")
+			return
+		}
 
 		// process
-		p, err := h.rp.GetOne(id)
+		var p internal.Product
+		if asOf != nil {
+			p, err = h.rp.GetOneAsOf(r.Context(), id, *asOf)
+		} else {
+			p, err = h.rp.GetOne(r.Context(), id)
+			if err == nil {
+				err = h.effectivePrices(r.Context(), &p)
+			}
+			if err == nil {
+				err = h.localizePrices(r.Context(), currency, &p)
+			}
+		}
 		if err != nil {
 			switch {
 			case errors.Is(err, internal.ErrProductNotFound):
 				response.Error(w, http.StatusNotFound, "product not found")
+			case errors.Is(err, internal.ErrExchangeRateNotFound):
+				response.Errorf(w, http.StatusBadRequest, "unsupported currency %s, there is no exchange rate into it", currency)
 			default:
-				response.Error(w, http.StatusInternalServerError, "internal server error")
+				repositoryError(w, err)
 			}
 			return
 		}
 
 		// response
 		// - serialize
-		data := ProductJSON{
-			ID:          p.ID,
-			Name:        p.Name,
-			Quantity:    p.Quantity,
-			CodeValue:   p.CodeValue,
-			IsPublished: p.IsPublished,
-			Expiration:  p.Expiration.Format(time.DateOnly),
-			Price:       p.Price,
+		data := serializeProduct(p, numericMoney(r))
+		if asOf == nil {
+			w.Header().Set("ETag", etag(p.Version))
 		}
 		response.JSON(w, http.StatusOK, map[string]any{"message": "product found", "data": data})
 	}
@@ -75,12 +633,13 @@ func (h *ProductsDefault) GetOne() http.HandlerFunc {
 
 // RequestBodyProductCreate is a struct that represents the request body of a product to create
 type RequestBodyProductCreate struct {
-	Name        string  `json:"name"`
-	Quantity    int     `json:"quantity"`
-	CodeValue   string  `json:"code_value"`
-	IsPublished bool    `json:"is_published"`
-	Expiration  string  `json:"expiration"`
-	Price       float64 `json:"price"`
+	Name        string         `json:"name"`
+	Quantity    int            `json:"quantity"`
+	CodeValue   string         `json:"code_value"`
+	IsPublished bool           `json:"is_published"`
+	Expiration  string         `json:"expiration"`
+	Price       internal.Money `json:"price"`
+	WarehouseID *int           `json:"warehouse_id"`
 }
 
 // Create creates a product
@@ -92,6 +651,14 @@ func (h *ProductsDefault) Create() http.HandlerFunc {
 			response.Error(w, http.StatusBadRequest, "invalid request body")
 			return
 		}
+		if body.Quantity < 0 {
+			response.Error(w, http.StatusBadRequest, "invalid quantity, must not be negative")
+			return
+		}
+		if !normalizePrice(&body.Price) {
+			response.Error(w, http.StatusBadRequest, invalidPriceMessage)
+			return
+		}
 		exp, err := time.Parse(time.DateOnly, body.Expiration)
 		if err != nil {
 			response.Error(w, http.StatusBadRequest, "invalid expiration date")
@@ -106,45 +673,180 @@ func (h *ProductsDefault) Create() http.HandlerFunc {
 			IsPublished: body.IsPublished,
 			Expiration:  exp,
 			Price:       body.Price,
+			WarehouseID: body.WarehouseID,
 		}
-		if err := h.rp.Store(&p); err != nil {
+		if err := h.rp.Store(r.Context(), &p); err != nil {
 			switch {
 			case errors.Is(err, internal.ErrProductNotUnique):
-				response.Error(w, http.StatusConflict, "product not unique")
+				response.Error(w, http.StatusConflict, conflictMessage("product not unique", err))
 			case errors.Is(err, internal.ErrProductRelation):
-				response.Error(w, http.StatusConflict, "product relation error")
+				response.Error(w, http.StatusConflict, conflictMessage("product relation error", err))
 			default:
-				response.Error(w, http.StatusInternalServerError, "internal server error")
+				repositoryError(w, err)
 			}
 			return
 		}
+		h.cacheProduct(p)
 
 		// response
 		// - serialize
-		data := ProductJSON{
-			ID:          p.ID,
-			Name:        p.Name,
-			Quantity:    p.Quantity,
-			CodeValue:   p.CodeValue,
-			IsPublished: p.IsPublished,
-			Expiration:  p.Expiration.Format(time.DateOnly),
-			Price:       p.Price,
-		}
+		data := serializeProduct(p, numericMoney(r))
+		w.Header().Set("ETag", etag(p.Version))
 		response.JSON(w, http.StatusCreated, map[string]any{"message": "product created", "data": data})
 	}
 }
 
+// maxBatchSize is the maximum number of products of a batch request
+const maxBatchSize = 1000
+
+// BatchResultJSON is a struct that represents the result of an item of a batch request in JSON
+type BatchResultJSON struct {
+	Index int             `json:"index"`
+	ID    *int            `json:"id,omitempty"`
+	Error *BatchErrorJSON `json:"error,omitempty"`
+}
+
+// BatchErrorJSON is a struct that represents the error of an item of a batch request in JSON
+type BatchErrorJSON struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// batchError returns the JSON error of an item of a batch request
+func batchError(err error) *BatchErrorJSON {
+	switch {
+	case errors.Is(err, internal.ErrProductNotUnique):
+		return &BatchErrorJSON{Code: "not_unique", Message: conflictMessage("product not unique", err)}
+	case errors.Is(err, internal.ErrProductRelation):
+		return &BatchErrorJSON{Code: "relation", Message: conflictMessage("product relation error", err)}
+	default:
+		return &BatchErrorJSON{Code: "internal", Message: "internal server error"}
+	}
+}
+
+// CreateBatch creates products in a single transaction
+// - mode all_or_nothing (default) creates every product or none, best_effort creates the valid ones
+// and reports the error of the rest
+func (h *ProductsDefault) CreateBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var atomic bool
+		switch r.URL.Query().Get("mode") {
+		case "", "all_or_nothing":
+			atomic = true
+		case "best_effort":
+		default:
+			response.Error(w, http.StatusBadRequest, "invalid mode, must be all_or_nothing or best_effort")
+			return
+		}
+		var body []RequestBodyProductCreate
+		if err := request.JSON(r, &body); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if len(body) == 0 || len(body) > maxBatchSize {
+			response.Errorf(w, http.StatusBadRequest, "invalid request body, must have between 1 and %d products", maxBatchSize)
+			return
+		}
+
+		// process
+		// - validate products
+		results := make([]BatchResultJSON, len(body))
+		ps := make([]internal.Product, 0, len(body))
+		indexes := make([]int, 0, len(body))
+		for i, b := range body {
+			results[i].Index = i
+			if b.Quantity < 0 {
+				results[i].Error = &BatchErrorJSON{Code: "invalid_quantity", Message: "invalid quantity, must not be negative"}
+				continue
+			}
+			if !normalizePrice(&b.Price) {
+				results[i].Error = &BatchErrorJSON{Code: "invalid_price", Message: invalidPriceMessage}
+				continue
+			}
+			exp, err := time.Parse(time.DateOnly, b.Expiration)
+			if err != nil {
+				results[i].Error = &BatchErrorJSON{Code: "invalid_expiration", Message: "invalid expiration date"}
+				continue
+			}
+			ps = append(ps, internal.Product{
+				Name:        b.Name,
+				Quantity:    b.Quantity,
+				CodeValue:   b.CodeValue,
+				IsPublished: b.IsPublished,
+				Expiration:  exp,
+				Price:       b.Price,
+				WarehouseID: b.WarehouseID,
+			})
+			indexes = append(indexes, i)
+		}
+		if atomic && len(ps) < len(body) {
+			response.JSON(w, http.StatusUnprocessableEntity, map[string]any{"message": "products not created", "data": results})
+			return
+		}
+		// - store products
+		if len(ps) > 0 {
+			errs, err := h.rp.StoreBatch(r.Context(), ps, atomic)
+			failed := false
+			for j, e := range errs {
+				if e != nil {
+					results[indexes[j]].Error = batchError(e)
+					failed = true
+				}
+			}
+			if err != nil {
+				switch {
+				case !failed:
+					repositoryError(w, err)
+				case errors.Is(err, internal.ErrProductNotUnique), errors.Is(err, internal.ErrProductRelation):
+					response.JSON(w, http.StatusConflict, map[string]any{"message": "products not created", "data": results})
+				default:
+					response.JSON(w, http.StatusInternalServerError, map[string]any{"message": "products not created", "data": results})
+				}
+				return
+			}
+			for j, p := range ps {
+				if errs[j] == nil {
+					id := p.ID
+					results[indexes[j]].ID = &id
+					h.cacheProduct(p)
+				}
+			}
+		}
+
+		// response
+		created := 0
+		for _, res := range results {
+			if res.Error == nil {
+				created++
+			}
+		}
+		code := http.StatusCreated
+		if created < len(body) {
+			code = http.StatusMultiStatus
+		}
+		response.JSON(w, code, map[string]any{"message": fmt.Sprintf("%d of %d products created", created, len(body)), "data": results})
+	}
+}
+
+// quantityReadOnlyMessage is the error message of an update that changes the quantity of a product
+const quantityReadOnlyMessage = "invalid request body: quantity can only be changed through stock movements"
+
 // RequestBodyProductUpdate is a struct that represents the request body of a product to update
+// - a null warehouse_id takes the product out of its warehouse
+// - quantity may only be sent unchanged, stock moves through the ledger
 type RequestBodyProductUpdate struct {
-	Name        string  `json:"name"`
-	Quantity    int     `json:"quantity"`
-	CodeValue   string  `json:"code_value"`
-	IsPublished bool    `json:"is_published"`
-	Expiration  string  `json:"expiration"`
-	Price       float64 `json:"price"`
+	Name        string         `json:"name"`
+	Quantity    int            `json:"quantity"`
+	CodeValue   string         `json:"code_value"`
+	IsPublished bool           `json:"is_published"`
+	Expiration  string         `json:"expiration"`
+	Price       internal.Money `json:"price"`
+	WarehouseID *int           `json:"warehouse_id"`
 }
 
 // Update updates a product
+// - the If-Match header must hold the ETag of the version being patched, so concurrent changes are not overwritten
 func (h *ProductsDefault) Update() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
@@ -153,19 +855,27 @@ func (h *ProductsDefault) Update() http.HandlerFunc {
 			response.Error(w, http.StatusBadRequest, "invalid id")
 			return
 		}
+		if r.Header.Get("If-Match") == "" {
+			response.Error(w, http.StatusPreconditionRequired, "If-Match header required")
+			return
+		}
 
 		// process
 		// - get product
-		p, err := h.rp.GetOne(id)
+		p, err := h.rp.GetOne(r.Context(), id)
 		if err != nil {
 			switch {
 			case errors.Is(err, internal.ErrProductNotFound):
 				response.Error(w, http.StatusNotFound, "product not found")
 			default:
-				response.Error(w, http.StatusInternalServerError, "internal server error")
+				repositoryError(w, err)
 			}
 			return
 		}
+		if !ifMatch(r, p.Version) {
+			response.Error(w, http.StatusPreconditionFailed, "product version mismatch")
+			return
+		}
 		// - patch product
 		body := RequestBodyProductUpdate{
 			Name:        p.Name,
@@ -174,51 +884,241 @@ func (h *ProductsDefault) Update() http.HandlerFunc {
 			IsPublished: p.IsPublished,
 			Expiration:  p.Expiration.Format(time.DateOnly),
 			Price:       p.Price,
+			WarehouseID: p.WarehouseID,
 		}
 		if err := request.JSON(r, &body); err != nil {
 			response.Error(w, http.StatusBadRequest, "invalid request body")
 			return
 		}
+		if body.Quantity != p.Quantity {
+			response.Error(w, http.StatusBadRequest, quantityReadOnlyMessage)
+			return
+		}
+		if !normalizePrice(&body.Price) {
+			response.Error(w, http.StatusBadRequest, invalidPriceMessage)
+			return
+		}
 		exp, err := time.Parse(time.DateOnly, body.Expiration)
 		if err != nil {
 			response.Error(w, http.StatusBadRequest, "invalid expiration date")
 			return
 		}
 		p.Name = body.Name
-		p.Quantity = body.Quantity
 		p.CodeValue = body.CodeValue
 		p.IsPublished = body.IsPublished
 		p.Expiration = exp
 		p.Price = body.Price
+		p.WarehouseID = body.WarehouseID
 		// - update product
-		if err := h.rp.Update(&p); err != nil {
+		if err := h.rp.Update(r.Context(), &p); err != nil {
 			switch {
+			case errors.Is(err, internal.ErrProductVersion):
+				response.Error(w, http.StatusPreconditionFailed, "product version mismatch")
+			case errors.Is(err, internal.ErrProductNotFound):
+				response.Error(w, http.StatusNotFound, "product not found")
 			case errors.Is(err, internal.ErrProductNotUnique):
-				response.Error(w, http.StatusConflict, "product not unique")
+				response.Error(w, http.StatusConflict, conflictMessage("product not unique", err))
 			case errors.Is(err, internal.ErrProductRelation):
-				response.Error(w, http.StatusConflict, "product relation error")
+				response.Error(w, http.StatusConflict, conflictMessage("product relation error", err))
 			default:
-				response.Error(w, http.StatusInternalServerError, "internal server error")
+				repositoryError(w, err)
 			}
 			return
 		}
+		h.cacheProduct(p)
 
 		// response
 		// - serialize
-		data := ProductJSON{
-			ID:          p.ID,
-			Name:        p.Name,
-			Quantity:    p.Quantity,
-			CodeValue:   p.CodeValue,
-			IsPublished: p.IsPublished,
-			Expiration:  p.Expiration.Format(time.DateOnly),
-			Price:       p.Price,
-		}
+		data := serializeProduct(p, numericMoney(r))
+		w.Header().Set("ETag", etag(p.Version))
 		response.JSON(w, http.StatusOK, map[string]any{"message": "product updated", "data": data})
 	}
 }
 
-// Delete deletes a product by id
+// RequestBodyProductSelector is a struct that represents the products selected by a batch request
+type RequestBodyProductSelector struct {
+	IDs         []int  `json:"ids"`
+	Filter      string `json:"filter"`
+	MaxAffected int    `json:"max_affected"`
+	DryRun      bool   `json:"dry_run"`
+}
+
+// parse returns the selector and options of a batch request
+func (b RequestBodyProductSelector) parse(maxAffected int) (s internal.ProductSelector, o internal.BatchOptions, err error) {
+	if (len(b.IDs) == 0) == (b.Filter == "") {
+		err = errors.New("either ids or filter must be set")
+		return
+	}
+	if len(b.IDs) > maxBatchSize {
+		err = fmt.Errorf("ids must have at most %d elements", maxBatchSize)
+		return
+	}
+	s.IDs = b.IDs
+	if b.Filter != "" {
+		if s.Filter, err = filter.Parse(b.Filter, productFields); err != nil {
+			err = fmt.Errorf("invalid filter: %w", err)
+			return
+		}
+	}
+	o = internal.BatchOptions{MaxAffected: maxAffected, DryRun: b.DryRun}
+	if b.MaxAffected < 0 || (maxAffected > 0 && b.MaxAffected > maxAffected) {
+		err = fmt.Errorf("max_affected must be between 1 and %d", maxAffected)
+		return
+	}
+	if b.MaxAffected > 0 {
+		o.MaxAffected = b.MaxAffected
+	}
+	return
+}
+
+// batchResponse writes the response of a batch update or delete of n products
+// - a dry run reports whether the operation would exceed the maximum number of products, rather than failing on it
+func batchResponse(w http.ResponseWriter, verb string, n int, o internal.BatchOptions, exceeds bool) {
+	message := fmt.Sprintf("%d products %s", n, verb)
+	data := map[string]any{"affected": n, "dry_run": o.DryRun}
+	if o.DryRun {
+		message = fmt.Sprintf("%d products would be %s", n, verb)
+		if exceeds {
+			message = fmt.Sprintf("%s, exceeds the maximum of %d", message, o.MaxAffected)
+		}
+		data["would_exceed_limit"] = exceeds
+	}
+	response.JSON(w, http.StatusOK, map[string]any{"message": message, "data": data})
+}
+
+// RequestBodyProductUpdateBatch is a struct that represents the request body of a batch update
+type RequestBodyProductUpdateBatch struct {
+	RequestBodyProductSelector
+	Changes struct {
+		Name        *string         `json:"name"`
+		Quantity    *int            `json:"quantity"`
+		CodeValue   *string         `json:"code_value"`
+		IsPublished *bool           `json:"is_published"`
+		Expiration  *string         `json:"expiration"`
+		Price       *internal.Money `json:"price"`
+		WarehouseID *int            `json:"warehouse_id"`
+	} `json:"changes"`
+}
+
+// UpdateBatch updates the products selected by ids or by a filter in a single transaction
+// - dry_run only reports the number of products that would be updated, and whether it exceeds max_affected
+func (h *ProductsDefault) UpdateBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body RequestBodyProductUpdateBatch
+		if err := request.JSON(r, &body); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		s, o, err := body.parse(h.maxAffected)
+		if err != nil {
+			response.Errorf(w, http.StatusBadRequest, "invalid request body: %s", err)
+			return
+		}
+		if body.Changes.Quantity != nil {
+			response.Error(w, http.StatusBadRequest, quantityReadOnlyMessage)
+			return
+		}
+		if body.Changes.Price != nil && !normalizePrice(body.Changes.Price) {
+			response.Error(w, http.StatusBadRequest, invalidPriceMessage)
+			return
+		}
+		patch := internal.ProductPatch{
+			Name:        body.Changes.Name,
+			CodeValue:   body.Changes.CodeValue,
+			IsPublished: body.Changes.IsPublished,
+			Price:       body.Changes.Price,
+			WarehouseID: body.Changes.WarehouseID,
+		}
+		if body.Changes.Expiration != nil {
+			exp, err := time.Parse(time.DateOnly, *body.Changes.Expiration)
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "invalid expiration date")
+				return
+			}
+			patch.Expiration = &exp
+		}
+		if patch == (internal.ProductPatch{}) {
+			response.Error(w, http.StatusBadRequest, "invalid request body: changes must not be empty")
+			return
+		}
+
+		// process
+		n, err := h.rp.UpdateBatch(r.Context(), s, patch, o)
+		exceeds := o.DryRun && errors.Is(err, internal.ErrProductBatchLimit)
+		if err != nil && !exceeds {
+			switch {
+			case errors.Is(err, internal.ErrProductBatchLimit):
+				response.Errorf(w, http.StatusUnprocessableEntity, "%d products selected, exceeds the maximum of %d", n, o.MaxAffected)
+			case errors.Is(err, internal.ErrProductNotUnique):
+				response.Error(w, http.StatusConflict, conflictMessage("product not unique", err))
+			case errors.Is(err, internal.ErrProductRelation):
+				response.Error(w, http.StatusConflict, conflictMessage("product relation error", err))
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+		if !o.DryRun && n > 0 && (patch.Name != nil || patch.CodeValue != nil) {
+			h.names.Reset()
+			h.codes.Reset()
+		}
+
+		// response
+		batchResponse(w, "updated", n, o, exceeds)
+	}
+}
+
+// RequestBodyProductAdjustQuantity is a struct that represents the request body of a quantity adjust
+type RequestBodyProductAdjustQuantity struct {
+	Delta int `json:"delta"`
+}
+
+// AdjustQuantity adds a delta to the quantity of a product atomically
+// - no version is required, concurrent adjusts of the same product are all applied
+// - an adjust that would take the quantity below zero is rejected with a conflict
+func (h *ProductsDefault) AdjustQuantity() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var body RequestBodyProductAdjustQuantity
+		if err := request.JSON(r, &body); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if body.Delta == 0 {
+			response.Error(w, http.StatusBadRequest, "invalid delta, must not be zero")
+			return
+		}
+
+		// process
+		p, err := h.rp.AdjustQuantity(r.Context(), id, body.Delta)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrProductNotFound):
+				response.Error(w, http.StatusNotFound, "product not found")
+			case errors.Is(err, internal.ErrStockInsufficient):
+				response.Error(w, http.StatusConflict, "insufficient stock")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		// - serialize
+		data := serializeProduct(p, numericMoney(r))
+		w.Header().Set("ETag", etag(p.Version))
+		response.JSON(w, http.StatusOK, map[string]any{"message": "quantity adjusted", "data": data})
+	}
+}
+
+// Delete moves a product to the trash
+// - purge=true deletes it permanently instead, whether it is in the trash or not, and requires the admin role
 func (h *ProductsDefault) Delete() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// request
@@ -227,14 +1127,123 @@ func (h *ProductsDefault) Delete() http.HandlerFunc {
 			response.Error(w, http.StatusBadRequest, "invalid id")
 			return
 		}
+		purge := false
+		if s := r.URL.Query().Get("purge"); s != "" {
+			purge, err = strconv.ParseBool(s)
+			if err != nil {
+				response.Error(w, http.StatusBadRequest, "invalid purge, must be true or false")
+				return
+			}
+		}
+		if purge && !auth.IsAdmin(r.Context()) {
+			response.Error(w, http.StatusForbidden, "admin role required to purge a product")
+			return
+		}
+
+		// process
+		del := h.rp.Delete
+		if purge {
+			del = h.rp.Purge
+		}
+		if err := del(r.Context(), id); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrProductNotFound):
+				response.Error(w, http.StatusNotFound, "product not found")
+			case errors.Is(err, internal.ErrProductRelation):
+				response.Error(w, http.StatusConflict, conflictMessage("product relation error", err))
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+		h.uncacheProduct(id)
+
+		// response
+		message := "product deleted"
+		if purge {
+			message = "product purged"
+		}
+		response.JSON(w, http.StatusOK, map[string]any{"message": message, "data": id})
+	}
+}
+
+// Restore moves a product out of the trash
+func (h *ProductsDefault) Restore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		if err := h.rp.Restore(r.Context(), id); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrProductNotFound):
+				response.Error(w, http.StatusNotFound, "product not found in trash")
+			case errors.Is(err, internal.ErrProductNotUnique):
+				response.Error(w, http.StatusConflict, conflictMessage("product not unique, another product took its code since it was trashed", err))
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+		p, err := h.rp.GetOne(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrProductNotFound):
+				response.Error(w, http.StatusNotFound, "product not found")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+		h.cacheProduct(p)
+
+		// response
+		// - serialize
+		data := serializeProduct(p, numericMoney(r))
+		response.JSON(w, http.StatusOK, map[string]any{"message": "product restored", "data": data})
+	}
+}
+
+// DeleteBatch deletes the products selected by ids or by a filter in a single transaction
+// - dry_run only reports the number of products that would be deleted, and whether it exceeds max_affected
+func (h *ProductsDefault) DeleteBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body RequestBodyProductSelector
+		if err := request.JSON(r, &body); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		s, o, err := body.parse(h.maxAffected)
+		if err != nil {
+			response.Errorf(w, http.StatusBadRequest, "invalid request body: %s", err)
+			return
+		}
 
 		// process
-		if err := h.rp.Delete(id); err != nil {
-			response.Error(w, http.StatusInternalServerError, "internal server error")
+		n, err := h.rp.DeleteBatch(r.Context(), s, o)
+		exceeds := o.DryRun && errors.Is(err, internal.ErrProductBatchLimit)
+		if err != nil && !exceeds {
+			switch {
+			case errors.Is(err, internal.ErrProductBatchLimit):
+				response.Errorf(w, http.StatusUnprocessableEntity, "%d products selected, exceeds the maximum of %d", n, o.MaxAffected)
+			case errors.Is(err, internal.ErrProductRelation):
+				response.Error(w, http.StatusConflict, conflictMessage("product relation error", err))
+			default:
+				repositoryError(w, err)
+			}
 			return
 		}
+		if !o.DryRun && n > 0 {
+			h.names.Reset()
+			h.codes.Reset()
+		}
 
 		// response
-		response.JSON(w, http.StatusOK, map[string]any{"message": "product deleted", "data": id})
+		batchResponse(w, "deleted", n, o, exceeds)
 	}
 }
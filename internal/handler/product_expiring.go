@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/filter"
+	"app/platform/web/response"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultExpiringWithin is the default window of the listing of the products expiring soon
+	defaultExpiringWithin = 7 * 24 * time.Hour
+	// maxExpiringDays is the maximum window in days of the listing of the products expiring soon
+	maxExpiringDays = 366
+	// maxExpiringWithin is the maximum window of the listing of the products expiring soon
+	maxExpiringWithin = maxExpiringDays * 24 * time.Hour
+)
+
+// errWithinInvalid is the error of a window of the listing of the products expiring soon that is invalid or out of range
+var errWithinInvalid = errors.New("invalid within")
+
+// parseWithin parses a window such as "7d", a number of days, or a duration such as "36h", up to maxExpiringWithin
+// - the number of days is checked before it is turned into a duration, so a huge one can not wrap around
+func parseWithin(s string) (d time.Duration, err error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, e := strconv.Atoi(days)
+		if e != nil || n < 0 || n > maxExpiringDays {
+			err = errWithinInvalid
+			return
+		}
+		d = time.Duration(n) * 24 * time.Hour
+		return
+	}
+	if d, err = time.ParseDuration(s); err != nil || d < 0 || d > maxExpiringWithin {
+		d, err = 0, errWithinInvalid
+	}
+	return
+}
+
+// Expiring returns a page of the products, published or not, whose expiration falls from today to within from now,
+// soonest first
+// - within is a number of days such as 7d or a duration such as 36h, 7 days by default
+// - filter narrows the products down as in the listing of the products
+func (h *ProductsDefault) Expiring() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		limit, err := queryInt(r, "limit", defaultLimit)
+		if err != nil || limit < 1 || limit > maxLimit {
+			response.Errorf(w, http.StatusBadRequest, "invalid limit, must be between 1 and %d", maxLimit)
+			return
+		}
+		offset, err := queryInt(r, "offset", 0)
+		if err != nil || offset < 0 {
+			response.Error(w, http.StatusBadRequest, "invalid offset")
+			return
+		}
+		within := defaultExpiringWithin
+		if s := r.URL.Query().Get("within"); s != "" {
+			within, err = parseWithin(s)
+			if err != nil {
+				response.Errorf(w, http.StatusBadRequest, "invalid within, must be a number of days such as 7d or a duration such as 36h, up to %dd", maxExpiringDays)
+				return
+			}
+		}
+		// - expirations are dates, so the window runs from the date of today to the date within from now
+		now := time.Now()
+		from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		to := now.Add(within)
+		to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+		q := internal.ProductQuery{
+			Limit:  limit,
+			Offset: offset,
+			Filter: filter.And{
+				Left:  filter.Comparison{Field: "expiration", Op: filter.OpGe, Value: from},
+				Right: filter.Comparison{Field: "expiration", Op: filter.OpLe, Value: to},
+			},
+			Sort: []internal.ProductSort{{Field: "expiration"}},
+		}
+		if expr := r.URL.Query().Get("filter"); expr != "" {
+			e, err := filter.Parse(expr, productFields)
+			if err != nil {
+				response.Errorf(w, http.StatusBadRequest, "invalid filter: %s", err)
+				return
+			}
+			q.Filter = filter.And{Left: q.Filter, Right: e}
+		}
+
+		// process
+		ps, total, err := h.rp.GetAll(r.Context(), q)
+		if err == nil {
+			pps := make([]*internal.Product, len(ps))
+			for i := range ps {
+				pps[i] = &ps[i]
+			}
+			err = h.effectivePrices(r.Context(), pps...)
+		}
+		if err != nil {
+			repositoryError(w, err)
+			return
+		}
+
+		// response
+		// - serialize
+		data := make([]ProductJSON, 0, len(ps))
+		for _, p := range ps {
+			data = append(data, serializeProduct(p, numericMoney(r)))
+		}
+		// - pagination
+		pagination := PaginationJSON{Total: &total, Limit: limit, Offset: offset}
+		if offset+limit < total {
+			pagination.Next = pageLink(r, map[string]string{"limit": strconv.Itoa(limit), "offset": strconv.Itoa(offset + limit)})
+		}
+		if offset > 0 {
+			pagination.Prev = pageLink(r, map[string]string{"limit": strconv.Itoa(limit), "offset": strconv.Itoa(max(offset-limit, 0))})
+		}
+		window := map[string]string{"from": from.Format(time.DateOnly), "to": to.Format(time.DateOnly)}
+		response.JSON(w, http.StatusOK, map[string]any{"message": "expiring products found", "data": data, "window": window, "pagination": pagination})
+	}
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/filter"
+	"app/platform/web/response"
+	"encoding/csv"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// exportFlushRows is the number of rows of an export written between flushes
+const exportFlushRows = 100
+
+// Export streams every product matching the filter, in the sort order, as csv or ndjson
+// - rows are written as they are read from the database, so memory does not grow with the number of products
+// - prices are the effective ones, as in the rest of the endpoints
+// - the export stops when the client disconnects
+func (h *ProductsDefault) Export() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "csv"
+		}
+		if format != "csv" && format != "ndjson" {
+			response.Error(w, http.StatusBadRequest, "invalid format, must be csv or ndjson")
+			return
+		}
+		var q internal.ProductQuery
+		var err error
+		if expr := r.URL.Query().Get("filter"); expr != "" {
+			q.Filter, err = filter.Parse(expr, productFields)
+			if err != nil {
+				response.Errorf(w, http.StatusBadRequest, "invalid filter: %s", err)
+				return
+			}
+		}
+		q.Sort, err = parseSort(r.URL.Query().Get("sort"))
+		if err != nil {
+			response.Errorf(w, http.StatusBadRequest, "invalid sort: %s", err)
+			return
+		}
+
+		// response
+		// - the header is written with the first row, so errors before it can still be reported
+		var s *response.Stream
+		var write func(p internal.Product) error
+		start := func() {
+			switch format {
+			case "csv":
+				w.Header().Set("Content-Disposition", `attachment; filename="products.csv"`)
+				s = response.NewStream(w, http.StatusOK, "text/csv; charset=utf-8")
+				cw := csv.NewWriter(s)
+				cw.Write([]string{"id", "name", "quantity", "code_value", "is_published", "expiration", "price", "warehouse_id"})
+				write = func(p internal.Product) error {
+					var warehouseID string
+					if p.WarehouseID != nil {
+						warehouseID = strconv.Itoa(*p.WarehouseID)
+					}
+					cw.Write([]string{
+						strconv.Itoa(p.ID),
+						p.Name,
+						strconv.Itoa(p.Quantity),
+						p.CodeValue,
+						strconv.FormatBool(p.IsPublished),
+						p.Expiration.Format(time.DateOnly),
+						p.Price.String(),
+						warehouseID,
+					})
+					cw.Flush()
+					return cw.Error()
+				}
+			case "ndjson":
+				s = response.NewStream(w, http.StatusOK, "application/x-ndjson")
+				enc := json.NewEncoder(s)
+				numeric := numericMoney(r)
+				write = func(p internal.Product) error {
+					return enc.Encode(serializeProduct(p, numeric))
+				}
+			}
+		}
+
+		// process
+		// - rows are written in chunks, so the scheduled prices already due are resolved once per chunk
+		rows := 0
+		chunk := make([]internal.Product, 0, exportFlushRows)
+		writeChunk := func() error {
+			pps := make([]*internal.Product, len(chunk))
+			for i := range chunk {
+				pps[i] = &chunk[i]
+			}
+			if err := h.effectivePrices(r.Context(), pps...); err != nil {
+				return err
+			}
+			for _, p := range chunk {
+				if err := write(p); err != nil {
+					return err
+				}
+				rows++
+			}
+			chunk = chunk[:0]
+			s.Flush()
+			return nil
+		}
+		err = h.rp.Export(r.Context(), q, func(p internal.Product) error {
+			if s == nil {
+				start()
+			}
+			chunk = append(chunk, p)
+			if len(chunk) == exportFlushRows {
+				return writeChunk()
+			}
+			return nil
+		})
+		if err == nil {
+			if s == nil {
+				start()
+			}
+			err = writeChunk()
+		}
+		if err != nil {
+			if s == nil {
+				repositoryError(w, err)
+				return
+			}
+			// - the status was already sent, the client sees a truncated body
+			log.Printf("handler: export interrupted after %d rows: %v", rows, err)
+			return
+		}
+	}
+}
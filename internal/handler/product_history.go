@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/web/response"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ProductChangeJSON is a struct that represents the change of a field of a product in JSON
+type ProductChangeJSON struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// ProductAuditJSON is a struct that represents a change of a product in JSON
+type ProductAuditJSON struct {
+	ID        int    `json:"id"`
+	ProductID int    `json:"product_id"`
+	Version   int    `json:"version"`
+	Operation string `json:"operation"`
+	Actor     string `json:"actor"`
+	ChangedAt string `json:"changed_at"`
+	// Changes maps the fields that changed to their values before and after the change
+	Changes map[string]ProductChangeJSON `json:"changes"`
+}
+
+// productValues returns the fields of the JSON representation of a product, nil for a nil product
+func productValues(p *internal.Product) (values map[string]any, err error) {
+	if p == nil {
+		return
+	}
+	b, err := json.Marshal(serializeProduct(*p, false))
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(b, &values)
+	return
+}
+
+// serializeProductAudit returns the JSON representation of a change of a product
+// - the version is not reported as a change, it increases with every change
+func serializeProductAudit(a internal.ProductAudit) (data ProductAuditJSON, err error) {
+	before, err := productValues(a.Before)
+	if err != nil {
+		return
+	}
+	after, err := productValues(a.After)
+	if err != nil {
+		return
+	}
+
+	data = ProductAuditJSON{
+		ID:        a.ID,
+		ProductID: a.ProductID,
+		Version:   a.Version,
+		Operation: a.Operation,
+		Actor:     a.Actor,
+		ChangedAt: a.ChangedAt.UTC().Format(time.RFC3339Nano),
+		Changes:   make(map[string]ProductChangeJSON),
+	}
+	fields := make(map[string]bool)
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+	for field := range fields {
+		if field == "version" {
+			continue
+		}
+		bv, av := before[field], after[field]
+		if bv != av {
+			data.Changes[field] = ProductChangeJSON{Before: bv, After: av}
+		}
+	}
+	return
+}
+
+// History returns a page of the changes of a product, newest first
+// - the history of a purged product is kept
+func (h *ProductsDefault) History() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		limit, err := queryInt(r, "limit", defaultLimit)
+		if err != nil || limit < 1 || limit > maxLimit {
+			response.Errorf(w, http.StatusBadRequest, "invalid limit, must be between 1 and %d", maxLimit)
+			return
+		}
+		offset, err := queryInt(r, "offset", 0)
+		if err != nil || offset < 0 {
+			response.Error(w, http.StatusBadRequest, "invalid offset")
+			return
+		}
+
+		// process
+		as, total, err := h.rp.History(r.Context(), id, limit, offset)
+		if err != nil {
+			repositoryError(w, err)
+			return
+		}
+		if total == 0 {
+			response.Error(w, http.StatusNotFound, "product not found")
+			return
+		}
+
+		// response
+		// - serialize
+		data := make([]ProductAuditJSON, 0, len(as))
+		for _, a := range as {
+			d, err := serializeProductAudit(a)
+			if err != nil {
+				response.Error(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+			data = append(data, d)
+		}
+		// - pagination
+		pagination := PaginationJSON{Total: &total, Limit: limit, Offset: offset}
+		if offset+limit < total {
+			pagination.Next = pageLink(r, map[string]string{"limit": strconv.Itoa(limit), "offset": strconv.Itoa(offset + limit)})
+		}
+		if offset > 0 {
+			pagination.Prev = pageLink(r, map[string]string{"limit": strconv.Itoa(limit), "offset": strconv.Itoa(max(offset-limit, 0))})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{"message": "history found", "data": data, "pagination": pagination})
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/web/request"
+	"app/platform/web/response"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// importBatchSize is the number of rows of an import stored per batch
+const importBatchSize = 500
+
+// importColumns is the list of columns required in the header of an import
+var importColumns = []string{"name", "quantity", "code_value", "is_published", "expiration", "price"}
+
+// ImportAcceptedJSON is a struct that represents a row accepted by an import in JSON
+type ImportAcceptedJSON struct {
+	Line int `json:"line"`
+	ID   int `json:"id"`
+}
+
+// ImportRejectedJSON is a struct that represents a row rejected by an import in JSON
+type ImportRejectedJSON struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// ImportReportJSON is a struct that represents the report of an import in JSON
+type ImportReportJSON struct {
+	Accepted []ImportAcceptedJSON `json:"accepted"`
+	Rejected []ImportRejectedJSON `json:"rejected"`
+}
+
+// parseImportRecord returns the product of a csv record, or the reason it was rejected
+func parseImportRecord(record map[string]string) (p internal.Product, reason string) {
+	quantity, err := strconv.Atoi(record["quantity"])
+	if err != nil || quantity < 0 {
+		reason = fmt.Sprintf("invalid quantity %q, must be a non-negative integer", record["quantity"])
+		return
+	}
+	isPublished, err := strconv.ParseBool(record["is_published"])
+	if err != nil {
+		reason = fmt.Sprintf("invalid is_published %q, must be true or false", record["is_published"])
+		return
+	}
+	exp, err := time.Parse(time.DateOnly, record["expiration"])
+	if err != nil {
+		reason = fmt.Sprintf("invalid expiration %q, must have the format YYYY-MM-DD", record["expiration"])
+		return
+	}
+	price, err := internal.ParseMoney(record["price"], internal.DefaultCurrency)
+	if err != nil || !normalizePrice(&price) {
+		reason = fmt.Sprintf("invalid price %q, must be a non-negative amount with at most %d decimals", record["price"], internal.PriceScale)
+		return
+	}
+	// - warehouse_id is optional, an empty value leaves the product out of any warehouse
+	var warehouseID *int
+	if s := record["warehouse_id"]; s != "" {
+		id, err := strconv.Atoi(s)
+		if err != nil {
+			reason = fmt.Sprintf("invalid warehouse_id %q, must be an integer", s)
+			return
+		}
+		warehouseID = &id
+	}
+
+	p = internal.Product{
+		Name:        record["name"],
+		Quantity:    quantity,
+		CodeValue:   record["code_value"],
+		IsPublished: isPublished,
+		Expiration:  exp,
+		Price:       price,
+		WarehouseID: warehouseID,
+	}
+	return
+}
+
+// Import creates the products of a csv body, whose header row names the fields of ProductJSON
+// - rows are stored in batches as they are read, rows that fail are reported with their line and reason
+func (h *ProductsDefault) Import() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		rd, err := request.CSV(r)
+		if err != nil {
+			switch {
+			case errors.Is(err, request.ErrRequestContentTypeNotCSV):
+				response.Error(w, http.StatusUnsupportedMediaType, "invalid content type, must be text/csv")
+			default:
+				response.Error(w, http.StatusBadRequest, "invalid request body")
+			}
+			return
+		}
+		columns := make(map[string]bool)
+		for _, name := range rd.Header() {
+			columns[name] = true
+		}
+		for _, name := range importColumns {
+			if !columns[name] {
+				response.Errorf(w, http.StatusBadRequest, "invalid header, missing column %q", name)
+				return
+			}
+		}
+
+		// process
+		report := ImportReportJSON{Accepted: []ImportAcceptedJSON{}, Rejected: []ImportRejectedJSON{}}
+		codes := make(map[string]int)
+		batch := make([]internal.Product, 0, importBatchSize)
+		lines := make([]int, 0, importBatchSize)
+		// - store the pending batch
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			errs, err := h.rp.StoreBatch(r.Context(), batch, false)
+			if err != nil {
+				return err
+			}
+			for i, p := range batch {
+				switch {
+				case errs[i] == nil:
+					report.Accepted = append(report.Accepted, ImportAcceptedJSON{Line: lines[i], ID: p.ID})
+					h.cacheProduct(p)
+				case errors.Is(errs[i], internal.ErrProductNotUnique):
+					report.Rejected = append(report.Rejected, ImportRejectedJSON{Line: lines[i], Reason: fmt.Sprintf("duplicate code_value %q", p.CodeValue)})
+				case errors.Is(errs[i], internal.ErrProductRelation):
+					report.Rejected = append(report.Rejected, ImportRejectedJSON{Line: lines[i], Reason: conflictMessage("product relation error", errs[i])})
+				default:
+					// - the rest of the batch is already committed, so the row is reported instead of failing the import
+					report.Rejected = append(report.Rejected, ImportRejectedJSON{Line: lines[i], Reason: "internal server error"})
+				}
+			}
+			batch, lines = batch[:0], lines[:0]
+			return nil
+		}
+		// - read the rows
+		for {
+			record, line, err := rd.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				if errors.Is(err, csv.ErrFieldCount) {
+					report.Rejected = append(report.Rejected, ImportRejectedJSON{Line: line, Reason: "wrong number of fields"})
+					continue
+				}
+				response.Errorf(w, http.StatusBadRequest, "invalid csv at line %d", line)
+				return
+			}
+
+			p, reason := parseImportRecord(record)
+			if reason == "" {
+				if first, ok := codes[p.CodeValue]; ok {
+					reason = fmt.Sprintf("duplicate code_value %q, already in line %d", p.CodeValue, first)
+				}
+			}
+			if reason != "" {
+				report.Rejected = append(report.Rejected, ImportRejectedJSON{Line: line, Reason: reason})
+				continue
+			}
+			codes[p.CodeValue] = line
+			batch = append(batch, p)
+			lines = append(lines, line)
+
+			if len(batch) == importBatchSize {
+				if err := flush(); err != nil {
+					repositoryError(w, err)
+					return
+				}
+			}
+		}
+		if err := flush(); err != nil {
+			repositoryError(w, err)
+			return
+		}
+
+		// response
+		code := http.StatusCreated
+		if len(report.Rejected) > 0 {
+			code = http.StatusMultiStatus
+		}
+		message := fmt.Sprintf("%d rows imported, %d rows rejected", len(report.Accepted), len(report.Rejected))
+		response.JSON(w, code, map[string]any{"message": message, "data": report})
+	}
+}
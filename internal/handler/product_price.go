@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/web/request"
+	"app/platform/web/response"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// PriceChangeJSON is a struct that represents a scheduled change of the price of a product in JSON
+type PriceChangeJSON struct {
+	ID          int       `json:"id"`
+	Price       MoneyJSON `json:"price"`
+	EffectiveAt string    `json:"effective_at"`
+	// Status is pending until the change is applied to the product, or superseded by a manual price, then applied
+	Status    string  `json:"status"`
+	AppliedAt *string `json:"applied_at"`
+	Actor     string  `json:"actor"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// serializePriceChange returns the JSON representation of a scheduled change of the price of a product
+func serializePriceChange(c internal.PriceChange, numeric bool) PriceChangeJSON {
+	data := PriceChangeJSON{
+		ID:          c.ID,
+		Price:       MoneyJSON{Money: c.Price, Numeric: numeric},
+		EffectiveAt: c.EffectiveAt.UTC().Format(time.RFC3339),
+		Status:      "pending",
+		Actor:       c.Actor,
+		CreatedAt:   c.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if c.AppliedAt != nil {
+		appliedAt := c.AppliedAt.UTC().Format(time.RFC3339)
+		data.AppliedAt = &appliedAt
+		data.Status = "applied"
+	}
+	return data
+}
+
+// Prices returns the effective price of a product with the timeline of its scheduled changes
+func (h *ProductsDefault) Prices() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		p, err := h.rp.GetOne(r.Context(), id)
+		if err == nil {
+			err = h.effectivePrices(r.Context(), &p)
+		}
+		var cs []internal.PriceChange
+		if err == nil {
+			cs, err = h.rc.GetAll(r.Context(), id)
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrProductNotFound):
+				response.Error(w, http.StatusNotFound, "product not found")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		// - serialize
+		changes := make([]PriceChangeJSON, 0, len(cs))
+		for _, c := range cs {
+			changes = append(changes, serializePriceChange(c, numericMoney(r)))
+		}
+		data := map[string]any{"price": MoneyJSON{Money: p.Price, Numeric: numericMoney(r)}, "changes": changes}
+		response.JSON(w, http.StatusOK, map[string]any{"message": "prices found", "data": data})
+	}
+}
+
+// RequestBodyPriceChange is a struct that represents the request body of a price change to schedule
+type RequestBodyPriceChange struct {
+	Price       internal.Money `json:"price"`
+	EffectiveAt string         `json:"effective_at"`
+}
+
+// SchedulePrice schedules a change of the price of a product at a future time
+func (h *ProductsDefault) SchedulePrice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var body RequestBodyPriceChange
+		if err := request.JSON(r, &body); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if !normalizePrice(&body.Price) {
+			response.Error(w, http.StatusBadRequest, invalidPriceMessage)
+			return
+		}
+		effectiveAt, err := time.Parse(time.RFC3339, body.EffectiveAt)
+		if err != nil || !effectiveAt.After(time.Now()) {
+			response.Error(w, http.StatusBadRequest, "invalid effective_at, must be a future RFC 3339 time")
+			return
+		}
+
+		// process
+		c := internal.PriceChange{
+			ProductID:   id,
+			Price:       body.Price,
+			EffectiveAt: effectiveAt,
+		}
+		if err := h.rc.Schedule(r.Context(), &c); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrProductNotFound):
+				response.Error(w, http.StatusNotFound, "product not found")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		// - serialize
+		data := serializePriceChange(c, numericMoney(r))
+		response.JSON(w, http.StatusCreated, map[string]any{"message": "price change scheduled", "data": data})
+	}
+}
+
+// CancelPrice deletes a pending change of the price of a product
+func (h *ProductsDefault) CancelPrice() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		changeID, err := strconv.Atoi(chi.URLParam(r, "price_id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid price change id")
+			return
+		}
+
+		// process
+		if err := h.rc.Cancel(r.Context(), id, changeID); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrPriceChangeNotFound):
+				response.Error(w, http.StatusNotFound, "pending price change not found")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{"message": "price change cancelled", "data": changeID})
+	}
+}
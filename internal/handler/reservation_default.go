@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/web/request"
+	"app/platform/web/response"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxReservationTTL is the maximum time a reservation may hold stock
+const maxReservationTTL = 24 * time.Hour
+
+// NewReservationsDefault returns a new instance of ReservationsDefault
+func NewReservationsDefault(rr internal.RepositoryReservations) *ReservationsDefault {
+	return &ReservationsDefault{
+		rr: rr,
+	}
+}
+
+// ReservationsDefault is a struct that represents the default reservation handler
+type ReservationsDefault struct {
+	// rr is the reservation repository
+	rr internal.RepositoryReservations
+}
+
+// ReservationJSON is a struct that represents a reservation in JSON
+type ReservationJSON struct {
+	ID         int     `json:"id"`
+	ProductID  int     `json:"product_id"`
+	Quantity   int     `json:"quantity"`
+	Status     string  `json:"status"`
+	Reference  string  `json:"reference"`
+	Actor      string  `json:"actor"`
+	CreatedAt  string  `json:"created_at"`
+	ExpiresAt  string  `json:"expires_at"`
+	ResolvedAt *string `json:"resolved_at"`
+}
+
+// serializeReservation returns the JSON representation of a reservation
+func serializeReservation(rs internal.Reservation) ReservationJSON {
+	data := ReservationJSON{
+		ID:        rs.ID,
+		ProductID: rs.ProductID,
+		Quantity:  rs.Quantity,
+		Status:    rs.Status,
+		Reference: rs.Reference,
+		Actor:     rs.Actor,
+		CreatedAt: rs.CreatedAt.UTC().Format(time.RFC3339),
+		ExpiresAt: rs.ExpiresAt.UTC().Format(time.RFC3339),
+	}
+	if rs.ResolvedAt != nil {
+		resolvedAt := rs.ResolvedAt.UTC().Format(time.RFC3339)
+		data.ResolvedAt = &resolvedAt
+	}
+	return data
+}
+
+// reservationError writes the response of an error of the reservation repository
+func reservationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, internal.ErrProductNotFound):
+		response.Error(w, http.StatusNotFound, "product not found")
+	case errors.Is(err, internal.ErrReservationNotFound):
+		response.Error(w, http.StatusNotFound, "reservation not found")
+	case errors.Is(err, internal.ErrReservationNotActive):
+		response.Error(w, http.StatusConflict, "reservation not active")
+	case errors.Is(err, internal.ErrStockInsufficient):
+		response.Error(w, http.StatusConflict, "insufficient stock")
+	default:
+		repositoryError(w, err)
+	}
+}
+
+// GetAll returns the stock of a product with its active reservations
+func (h *ReservationsDefault) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		quantity, reserved, err := h.rr.Stock(r.Context(), id)
+		if err != nil {
+			reservationError(w, err)
+			return
+		}
+		rs, err := h.rr.GetActive(r.Context(), id)
+		if err != nil {
+			reservationError(w, err)
+			return
+		}
+
+		// response
+		// - serialize
+		reservations := make([]ReservationJSON, 0, len(rs))
+		for _, res := range rs {
+			reservations = append(reservations, serializeReservation(res))
+		}
+		data := map[string]any{
+			"quantity":     quantity,
+			"reserved":     reserved,
+			"available":    quantity - reserved,
+			"reservations": reservations,
+		}
+		response.JSON(w, http.StatusOK, map[string]any{"message": "reservations found", "data": data})
+	}
+}
+
+// RequestBodyReservation is a struct that represents the request body of a reservation to make
+// - ttl is a duration such as "15m", up to maxReservationTTL
+type RequestBodyReservation struct {
+	Quantity  int    `json:"quantity"`
+	TTL       string `json:"ttl"`
+	Reference string `json:"reference"`
+}
+
+// Create holds stock of a product for the time to live of the body
+// - a reservation of more than the available stock is rejected with a conflict
+func (h *ReservationsDefault) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var body RequestBodyReservation
+		if err := request.JSON(r, &body); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if body.Quantity <= 0 {
+			response.Error(w, http.StatusBadRequest, "invalid quantity, must be positive")
+			return
+		}
+		if utf8.RuneCountInString(body.Reference) > maxReferenceLength {
+			response.Error(w, http.StatusBadRequest, invalidReferenceMessage)
+			return
+		}
+		ttl, err := time.ParseDuration(body.TTL)
+		if err != nil || ttl < time.Second || ttl > maxReservationTTL {
+			response.Errorf(w, http.StatusBadRequest, "invalid ttl, must be a duration between 1s and %s", maxReservationTTL)
+			return
+		}
+
+		// process
+		rs := internal.Reservation{
+			ProductID: id,
+			Quantity:  body.Quantity,
+			Reference: body.Reference,
+			ExpiresAt: time.Now().Add(ttl),
+		}
+		if err := h.rr.Reserve(r.Context(), &rs); err != nil {
+			reservationError(w, err)
+			return
+		}
+
+		// response
+		// - serialize
+		data := serializeReservation(rs)
+		response.JSON(w, http.StatusCreated, map[string]any{"message": "reservation created", "data": data})
+	}
+}
+
+// Confirm takes the stock of an active reservation out of its product
+func (h *ReservationsDefault) Confirm() http.HandlerFunc {
+	return h.resolve(h.rr.Confirm, "reservation confirmed")
+}
+
+// Cancel releases the stock of an active reservation
+func (h *ReservationsDefault) Cancel() http.HandlerFunc {
+	return h.resolve(h.rr.Cancel, "reservation cancelled")
+}
+
+// resolve returns a handler that resolves an active reservation with fn
+func (h *ReservationsDefault) resolve(fn func(ctx context.Context, productID, id int) (internal.Reservation, error), message string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		productID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		id, err := strconv.Atoi(chi.URLParam(r, "reservation_id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid reservation id")
+			return
+		}
+
+		// process
+		rs, err := fn(r.Context(), productID, id)
+		if err != nil {
+			reservationError(w, err)
+			return
+		}
+
+		// response
+		// - serialize
+		data := serializeReservation(rs)
+		response.JSON(w, http.StatusOK, map[string]any{"message": message, "data": data})
+	}
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/web/request"
+	"app/platform/web/response"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// NewStockMovementsDefault returns a new instance of StockMovementsDefault
+func NewStockMovementsDefault(rm internal.RepositoryStockMovements, rp internal.RepositoryProducts) *StockMovementsDefault {
+	return &StockMovementsDefault{
+		rm: rm,
+		rp: rp,
+	}
+}
+
+// StockMovementsDefault is a struct that represents the default stock ledger handler
+type StockMovementsDefault struct {
+	// rm is the stock ledger repository
+	rm internal.RepositoryStockMovements
+	// rp is the product repository
+	rp internal.RepositoryProducts
+}
+
+// maxReferenceLength is the maximum number of characters of the reference of a movement or a reservation
+const maxReferenceLength = 255
+
+// invalidReferenceMessage is the error message of a reference too long to be stored
+var invalidReferenceMessage = fmt.Sprintf("invalid reference, must be at most %d characters", maxReferenceLength)
+
+// movementReasons maps the kinds of movement to the reason codes they accept
+var movementReasons = map[string]map[string]bool{
+	internal.MovementInbound:    {"purchase": true, "return": true, "transfer": true},
+	internal.MovementOutbound:   {"sale": true, "damage": true, "expired": true, "transfer": true},
+	internal.MovementAdjustment: {"count": true, "correction": true},
+}
+
+// StockMovementJSON is a struct that represents a movement of the stock ledger in JSON
+type StockMovementJSON struct {
+	ID        int    `json:"id"`
+	ProductID int    `json:"product_id"`
+	Kind      string `json:"kind"`
+	Delta     int    `json:"delta"`
+	Reason    string `json:"reason"`
+	Reference string `json:"reference"`
+	Balance   int    `json:"balance"`
+	Actor     string `json:"actor"`
+	CreatedAt string `json:"created_at"`
+}
+
+// serializeStockMovement returns the JSON representation of a movement of the stock ledger
+func serializeStockMovement(m internal.StockMovement) StockMovementJSON {
+	return StockMovementJSON{
+		ID:        m.ID,
+		ProductID: m.ProductID,
+		Kind:      m.Kind,
+		Delta:     m.Delta,
+		Reason:    m.Reason,
+		Reference: m.Reference,
+		Balance:   m.Balance,
+		Actor:     m.Actor,
+		CreatedAt: m.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// RequestBodyStockMovement is a struct that represents the request body of a movement to record
+// - quantity is positive for inbound and outbound movements, and signed for adjustments
+type RequestBodyStockMovement struct {
+	Kind      string `json:"kind"`
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason"`
+	Reference string `json:"reference"`
+}
+
+// delta returns the change of the stock of the movement, or the message of the first invalid field of the body
+func (b RequestBodyStockMovement) delta() (delta int, msg string) {
+	reasons, ok := movementReasons[b.Kind]
+	if !ok {
+		msg = "invalid kind, must be inbound, outbound or adjustment"
+		return
+	}
+	if !reasons[b.Reason] {
+		msg = "invalid reason for a movement of kind " + b.Kind
+		return
+	}
+	switch b.Kind {
+	case internal.MovementInbound, internal.MovementOutbound:
+		if b.Quantity <= 0 {
+			msg = "invalid quantity, must be positive"
+			return
+		}
+		delta = b.Quantity
+		if b.Kind == internal.MovementOutbound {
+			delta = -delta
+		}
+	case internal.MovementAdjustment:
+		if b.Quantity == 0 {
+			msg = "invalid quantity, must not be zero"
+			return
+		}
+		delta = b.Quantity
+	}
+	return
+}
+
+// Create records a movement of the stock of a product
+// - a movement that would take the stock below zero is rejected with a conflict
+func (h *StockMovementsDefault) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		var body RequestBodyStockMovement
+		if err := request.JSON(r, &body); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		delta, msg := body.delta()
+		if msg != "" {
+			response.Error(w, http.StatusBadRequest, msg)
+			return
+		}
+		if utf8.RuneCountInString(body.Reference) > maxReferenceLength {
+			response.Error(w, http.StatusBadRequest, invalidReferenceMessage)
+			return
+		}
+
+		// process
+		m := internal.StockMovement{
+			ProductID: id,
+			Kind:      body.Kind,
+			Delta:     delta,
+			Reason:    body.Reason,
+			Reference: body.Reference,
+		}
+		if err := h.rm.Record(r.Context(), &m); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrProductNotFound):
+				response.Error(w, http.StatusNotFound, "product not found")
+			case errors.Is(err, internal.ErrStockInsufficient):
+				response.Error(w, http.StatusConflict, "insufficient stock")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		// - serialize
+		data := serializeStockMovement(m)
+		response.JSON(w, http.StatusCreated, map[string]any{"message": "stock movement recorded", "data": data})
+	}
+}
+
+// GetAll returns a page of the movements of the stock of a product, newest first
+func (h *StockMovementsDefault) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		limit, err := queryInt(r, "limit", defaultLimit)
+		if err != nil || limit < 1 || limit > maxLimit {
+			response.Errorf(w, http.StatusBadRequest, "invalid limit, must be between 1 and %d", maxLimit)
+			return
+		}
+		offset, err := queryInt(r, "offset", 0)
+		if err != nil || offset < 0 {
+			response.Error(w, http.StatusBadRequest, "invalid offset")
+			return
+		}
+
+		// process
+		_, err = h.rp.GetOne(r.Context(), id)
+		var ms []internal.StockMovement
+		var total int
+		if err == nil {
+			ms, total, err = h.rm.GetAll(r.Context(), id, limit, offset)
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrProductNotFound):
+				response.Error(w, http.StatusNotFound, "product not found")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		// - serialize
+		data := make([]StockMovementJSON, 0, len(ms))
+		for _, m := range ms {
+			data = append(data, serializeStockMovement(m))
+		}
+		// - pagination
+		pagination := PaginationJSON{Total: &total, Limit: limit, Offset: offset}
+		if offset+limit < total {
+			pagination.Next = pageLink(r, map[string]string{"limit": strconv.Itoa(limit), "offset": strconv.Itoa(offset + limit)})
+		}
+		if offset > 0 {
+			pagination.Prev = pageLink(r, map[string]string{"limit": strconv.Itoa(limit), "offset": strconv.Itoa(max(offset-limit, 0))})
+		}
+		response.JSON(w, http.StatusOK, map[string]any{"message": "stock movements found", "data": data, "pagination": pagination})
+	}
+}
@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"app/internal"
+	"app/platform/web/request"
+	"app/platform/web/response"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// NewWarehousesDefault returns a new instance of WarehousesDefault
+func NewWarehousesDefault(rw internal.RepositoryWarehouses) *WarehousesDefault {
+	return &WarehousesDefault{
+		rw: rw,
+	}
+}
+
+// WarehousesDefault is a struct that represents the default warehouse handler
+type WarehousesDefault struct {
+	// rw is the warehouse repository
+	rw internal.RepositoryWarehouses
+}
+
+// WarehouseJSON is a struct that represents a warehouse in JSON
+type WarehouseJSON struct {
+	ID      int    `json:"id"`
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// serializeWarehouse returns the JSON representation of a warehouse
+func serializeWarehouse(wh internal.Warehouse) WarehouseJSON {
+	return WarehouseJSON{
+		ID:      wh.ID,
+		Code:    wh.Code,
+		Name:    wh.Name,
+		Address: wh.Address,
+	}
+}
+
+// GetAll returns every warehouse
+func (h *WarehousesDefault) GetAll() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// process
+		whs, err := h.rw.GetAll(r.Context())
+		if err != nil {
+			repositoryError(w, err)
+			return
+		}
+
+		// response
+		// - serialize
+		data := make([]WarehouseJSON, 0, len(whs))
+		for _, wh := range whs {
+			data = append(data, serializeWarehouse(wh))
+		}
+		response.JSON(w, http.StatusOK, map[string]any{"message": "warehouses found", "data": data})
+	}
+}
+
+// GetOne returns a warehouse by id
+func (h *WarehousesDefault) GetOne() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		wh, err := h.rw.GetOne(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrWarehouseNotFound):
+				response.Error(w, http.StatusNotFound, "warehouse not found")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		// - serialize
+		data := serializeWarehouse(wh)
+		response.JSON(w, http.StatusOK, map[string]any{"message": "warehouse found", "data": data})
+	}
+}
+
+// RequestBodyWarehouse is a struct that represents the request body of a warehouse to create or update
+type RequestBodyWarehouse struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// validate returns the message of the first invalid field of the body, empty if it is valid
+func (b RequestBodyWarehouse) validate() string {
+	switch {
+	case b.Code == "":
+		return "invalid request body: code is required"
+	case b.Name == "":
+		return "invalid request body: name is required"
+	}
+	return ""
+}
+
+// Create creates a warehouse
+func (h *WarehousesDefault) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		var body RequestBodyWarehouse
+		if err := request.JSON(r, &body); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if msg := body.validate(); msg != "" {
+			response.Error(w, http.StatusBadRequest, msg)
+			return
+		}
+
+		// process
+		wh := internal.Warehouse{
+			Code:    body.Code,
+			Name:    body.Name,
+			Address: body.Address,
+		}
+		if err := h.rw.Store(r.Context(), &wh); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrWarehouseNotUnique):
+				response.Error(w, http.StatusConflict, conflictMessage("warehouse not unique", err))
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		// - serialize
+		data := serializeWarehouse(wh)
+		response.JSON(w, http.StatusCreated, map[string]any{"message": "warehouse created", "data": data})
+	}
+}
+
+// Update updates a warehouse, the fields missing in the body are left unchanged
+func (h *WarehousesDefault) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		// - get warehouse
+		wh, err := h.rw.GetOne(r.Context(), id)
+		if err != nil {
+			switch {
+			case errors.Is(err, internal.ErrWarehouseNotFound):
+				response.Error(w, http.StatusNotFound, "warehouse not found")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+		// - patch warehouse
+		body := RequestBodyWarehouse{
+			Code:    wh.Code,
+			Name:    wh.Name,
+			Address: wh.Address,
+		}
+		if err := request.JSON(r, &body); err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if msg := body.validate(); msg != "" {
+			response.Error(w, http.StatusBadRequest, msg)
+			return
+		}
+		wh.Code = body.Code
+		wh.Name = body.Name
+		wh.Address = body.Address
+		// - update warehouse
+		if err := h.rw.Update(r.Context(), &wh); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrWarehouseNotFound):
+				response.Error(w, http.StatusNotFound, "warehouse not found")
+			case errors.Is(err, internal.ErrWarehouseNotUnique):
+				response.Error(w, http.StatusConflict, conflictMessage("warehouse not unique", err))
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		// - serialize
+		data := serializeWarehouse(wh)
+		response.JSON(w, http.StatusOK, map[string]any{"message": "warehouse updated", "data": data})
+	}
+}
+
+// Delete deletes a warehouse
+// - a warehouse that still holds products, even in the trash, is not deleted
+func (h *WarehousesDefault) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// request
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+
+		// process
+		if err := h.rw.Delete(r.Context(), id); err != nil {
+			switch {
+			case errors.Is(err, internal.ErrWarehouseNotFound):
+				response.Error(w, http.StatusNotFound, "warehouse not found")
+			case errors.Is(err, internal.ErrWarehouseInUse):
+				response.Error(w, http.StatusConflict, "warehouse still holds products")
+			default:
+				repositoryError(w, err)
+			}
+			return
+		}
+
+		// response
+		response.JSON(w, http.StatusOK, map[string]any{"message": "warehouse deleted", "data": id})
+	}
+}
@@ -0,0 +1,371 @@
+package internal
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultCurrency is the currency of the amounts that do not name one, such as the prices stored of the products
+	DefaultCurrency = "USD"
+	// PriceScale is the number of decimal digits of the prices stored
+	PriceScale = 2
+	// maxMoneyScale is the maximum number of decimal digits of an amount
+	maxMoneyScale = 9
+)
+
+var (
+	// ErrMoneyInvalid is an error that will be returned when an amount can not be parsed
+	ErrMoneyInvalid = errors.New("money: invalid amount")
+	// ErrMoneyCurrency is an error that will be returned when amounts of different currencies are combined
+	ErrMoneyCurrency = errors.New("money: currency mismatch")
+	// ErrMoneyOverflow is an error that will be returned when an amount does not fit in its representation
+	ErrMoneyOverflow = errors.New("money: overflow")
+)
+
+// RoundingMode is a rule to round an amount to fewer decimal digits
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest amount and ties to the even one, so rounding errors do not add up in totals
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest amount and ties away from zero
+	RoundHalfUp
+	// RoundDown rounds towards zero
+	RoundDown
+)
+
+// Money is an exact amount of a currency, held as an integer number of units of 10^-scale
+// - the zero value is zero in DefaultCurrency
+// - amounts of different scales are equal when they have the same value, arithmetic keeps the larger scale
+type Money struct {
+	// units is the amount in units of 10^-scale
+	units int64
+	// scale is the number of decimal digits of the amount
+	scale int
+	// currency is the ISO 4217 code of the currency, empty for DefaultCurrency
+	currency string
+}
+
+// pow10 returns 10^n for 0 <= n <= 18
+func pow10(n int) int64 {
+	p := int64(1)
+	for ; n > 0; n-- {
+		p *= 10
+	}
+	return p
+}
+
+// NewMoney returns the amount of units of 10^-scale of a currency, an empty currency is DefaultCurrency
+func NewMoney(units int64, scale int, currency string) Money {
+	if currency == DefaultCurrency {
+		currency = ""
+	}
+	return Money{units: units, scale: scale, currency: currency}
+}
+
+// ParseMoney parses a decimal amount such as "-12.34" of a currency, keeping its number of decimal digits as its scale
+func ParseMoney(s string, currency string) (m Money, err error) {
+	// - a single sign is allowed
+	neg := strings.HasPrefix(s, "-")
+	digits := s
+	if neg || strings.HasPrefix(s, "+") {
+		digits = s[1:]
+	}
+	whole, frac, _ := strings.Cut(digits, ".")
+	if whole == "" && frac == "" || len(frac) > maxMoneyScale {
+		err = fmt.Errorf("%w: %q", ErrMoneyInvalid, s)
+		return
+	}
+	for _, c := range whole + frac {
+		if c < '0' || c > '9' {
+			err = fmt.Errorf("%w: %q", ErrMoneyInvalid, s)
+			return
+		}
+	}
+	var units int64
+	if n := strings.TrimLeft(whole+frac, "0"); n != "" {
+		if neg {
+			n = "-" + n
+		}
+		var e error
+		if units, e = strconv.ParseInt(n, 10, 64); e != nil {
+			err = fmt.Errorf("%w: %q", ErrMoneyOverflow, s)
+			return
+		}
+	}
+	m = NewMoney(units, len(frac), currency)
+	return
+}
+
+// ValidCurrency reports whether code is written as an ISO 4217 code of a currency, three upper case letters
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Units returns the amount in units of 10^-scale
+func (m Money) Units() int64 {
+	return m.units
+}
+
+// Scale returns the number of decimal digits of the amount
+func (m Money) Scale() int {
+	return m.scale
+}
+
+// Currency returns the ISO 4217 code of the currency of the amount
+func (m Money) Currency() string {
+	if m.currency == "" {
+		return DefaultCurrency
+	}
+	return m.currency
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the amount
+func (m Money) Sign() int {
+	switch {
+	case m.units < 0:
+		return -1
+	case m.units > 0:
+		return 1
+	}
+	return 0
+}
+
+// String returns the amount in decimal notation with all its decimal digits, such as "-12.30", without the currency
+func (m Money) String() string {
+	neg := m.units < 0
+	u := strconv.FormatUint(uint64(m.units), 10)
+	if neg {
+		u = strconv.FormatUint(uint64(-m.units), 10)
+	}
+	if m.scale > 0 {
+		if len(u) <= m.scale {
+			u = strings.Repeat("0", m.scale-len(u)+1) + u
+		}
+		u = u[:len(u)-m.scale] + "." + u[len(u)-m.scale:]
+	}
+	if neg {
+		u = "-" + u
+	}
+	return u
+}
+
+// Rescale returns the amount with the given number of decimal digits, rounded with mode if it has more
+func (m Money) Rescale(scale int, mode RoundingMode) (r Money, err error) {
+	if scale < 0 || scale > maxMoneyScale {
+		err = fmt.Errorf("%w: scale %d", ErrMoneyInvalid, scale)
+		return
+	}
+	r = m
+	r.scale = scale
+	switch {
+	case scale > m.scale:
+		p := pow10(scale - m.scale)
+		if m.units > math.MaxInt64/p || m.units < math.MinInt64/p {
+			err = ErrMoneyOverflow
+			return
+		}
+		r.units = m.units * p
+	case scale < m.scale:
+		r.units = roundDiv(m.units, pow10(m.scale-scale), mode)
+	}
+	return
+}
+
+// roundDiv returns n / d rounded with mode, for d > 0
+func roundDiv(n, d int64, mode RoundingMode) int64 {
+	q, rem := n/d, n%d
+	if rem == 0 {
+		return q
+	}
+	away := q + 1
+	if n < 0 {
+		rem, away = -rem, q-1
+	}
+	switch mode {
+	case RoundHalfUp:
+		if 2*rem >= d {
+			return away
+		}
+	case RoundHalfEven:
+		if 2*rem > d || 2*rem == d && q%2 != 0 {
+			return away
+		}
+	}
+	return q
+}
+
+// align returns the amounts at the larger of their scales, failing if they are of different currencies
+func align(a, b Money) (x, y Money, err error) {
+	if a.Currency() != b.Currency() {
+		err = fmt.Errorf("%w: %s and %s", ErrMoneyCurrency, a.Currency(), b.Currency())
+		return
+	}
+	scale := max(a.scale, b.scale)
+	if x, err = a.Rescale(scale, RoundDown); err != nil {
+		return
+	}
+	y, err = b.Rescale(scale, RoundDown)
+	return
+}
+
+// Add returns the sum of the amounts, which must be of the same currency
+func (m Money) Add(o Money) (r Money, err error) {
+	x, y, err := align(m, o)
+	if err != nil {
+		return
+	}
+	r = x
+	r.units = x.units + y.units
+	if (r.units > x.units) != (y.units > 0) {
+		r, err = Money{}, ErrMoneyOverflow
+	}
+	return
+}
+
+// Sub returns the difference of the amounts, which must be of the same currency
+func (m Money) Sub(o Money) (r Money, err error) {
+	x, y, err := align(m, o)
+	if err != nil {
+		return
+	}
+	r = x
+	r.units = x.units - y.units
+	if (r.units < x.units) != (y.units > 0) {
+		r, err = Money{}, ErrMoneyOverflow
+	}
+	return
+}
+
+// Mul returns the amount multiplied by n, such as the price of a quantity
+func (m Money) Mul(n int64) (r Money, err error) {
+	r = m
+	r.units = m.units * n
+	if m.units != 0 && (r.units/m.units != n || m.units == -1 && n == math.MinInt64) {
+		r, err = Money{}, ErrMoneyOverflow
+	}
+	return
+}
+
+// MulRat returns the amount multiplied by an exact ratio, such as an exchange rate, rounded to scale with mode
+func (m Money) MulRat(x *big.Rat, scale int, mode RoundingMode) (r Money, err error) {
+	if scale < 0 || scale > maxMoneyScale {
+		err = fmt.Errorf("%w: scale %d", ErrMoneyInvalid, scale)
+		return
+	}
+	// - the product in units of 10^-scale, as a fraction num/den with den > 0
+	v := new(big.Rat).SetFrac(big.NewInt(m.units), big.NewInt(pow10(m.scale)))
+	v.Mul(v, x)
+	v.Mul(v, new(big.Rat).SetInt64(pow10(scale)))
+	q, rem := new(big.Int).QuoRem(v.Num(), v.Denom(), new(big.Int))
+	if rem.Sign() != 0 {
+		twice := new(big.Int).Abs(rem)
+		twice.Lsh(twice, 1)
+		c := twice.Cmp(v.Denom())
+		if mode == RoundHalfUp && c >= 0 || mode == RoundHalfEven && (c > 0 || c == 0 && q.Bit(0) == 1) {
+			q.Add(q, big.NewInt(int64(v.Num().Sign())))
+		}
+	}
+	if !q.IsInt64() {
+		err = ErrMoneyOverflow
+		return
+	}
+	r = Money{units: q.Int64(), scale: scale, currency: m.currency}
+	return
+}
+
+// Convert returns the amount converted into currency at rate, the amount of currency worth one unit of the amount,
+// rounded to scale with mode
+func (m Money) Convert(currency string, rate *big.Rat, scale int, mode RoundingMode) (r Money, err error) {
+	if r, err = m.MulRat(rate, scale, mode); err != nil {
+		return
+	}
+	r = NewMoney(r.units, r.scale, currency)
+	return
+}
+
+// Cmp compares the amounts, which must be of the same currency, returning -1, 0 or +1
+func (m Money) Cmp(o Money) (c int, err error) {
+	x, y, err := align(m, o)
+	if err != nil {
+		return
+	}
+	switch {
+	case x.units < y.units:
+		c = -1
+	case x.units > y.units:
+		c = 1
+	}
+	return
+}
+
+// Equal reports whether the amounts are of the same currency and value, whatever their scales
+func (m Money) Equal(o Money) bool {
+	c, err := m.Cmp(o)
+	return err == nil && c == 0
+}
+
+// Scan implements sql.Scanner for a decimal column, the currency of the amount is left as it is
+func (m *Money) Scan(src any) (err error) {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("%w: can not scan %T", ErrMoneyInvalid, src)
+	}
+	r, err := ParseMoney(s, m.currency)
+	if err != nil {
+		return
+	}
+	*m = r
+	return
+}
+
+// Value implements driver.Valuer, the amount is written in decimal notation
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// MarshalJSON encodes the amount as a string in decimal notation, so no client reads it as a binary float
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON decodes an amount from a string or a number in decimal notation, exactly as written
+// - the currency of the amount is left as it is
+func (m *Money) UnmarshalJSON(b []byte) (err error) {
+	s := string(b)
+	if strings.HasPrefix(s, `"`) {
+		if err = json.Unmarshal(b, &s); err != nil {
+			return
+		}
+	}
+	r, err := ParseMoney(s, m.currency)
+	if err != nil {
+		return
+	}
+	*m = r
+	return
+}
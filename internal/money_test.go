@@ -0,0 +1,378 @@
+package internal_test
+
+import (
+	"app/internal"
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// money returns the amount parsed from s in currency, failing the test if it is invalid
+func money(t *testing.T, s string, currency string) internal.Money {
+	t.Helper()
+	m, err := internal.ParseMoney(s, currency)
+	require.NoError(t, err, s)
+	return m
+}
+
+// Tests for ParseMoney function
+func TestParseMoney(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		cases := []struct {
+			input string
+			units int64
+			scale int
+			str   string
+		}{
+			{"12.34", 1234, 2, "12.34"},
+			{"-12.30", -1230, 2, "-12.30"},
+			{"+5", 5, 0, "5"},
+			{"0.001", 1, 3, "0.001"},
+			{".5", 5, 1, "0.5"},
+			{"7.", 7, 0, "7"},
+			{"000", 0, 0, "0"},
+			{"9223372036854775807", math.MaxInt64, 0, "9223372036854775807"},
+			{"-9223372036854775808", math.MinInt64, 0, "-9223372036854775808"},
+			{"-92233720368.54775808", math.MinInt64, 8, "-92233720368.54775808"},
+		}
+		for _, c := range cases {
+			// act
+			m, err := internal.ParseMoney(c.input, "")
+
+			// assert
+			require.NoError(t, err, c.input)
+			require.Equal(t, c.units, m.Units(), c.input)
+			require.Equal(t, c.scale, m.Scale(), c.input)
+			require.Equal(t, c.str, m.String(), c.input)
+			require.Equal(t, internal.DefaultCurrency, m.Currency(), c.input)
+		}
+	})
+
+	t.Run("error - invalid", func(t *testing.T) {
+		cases := []string{"", "-", "+", ".", "-+5", "+-5", "--5", "1.2.3", "1e3", "1,5", " 1", "abc", "0.0000000001"}
+		for _, c := range cases {
+			// act
+			_, err := internal.ParseMoney(c, "")
+
+			// assert
+			require.ErrorIs(t, err, internal.ErrMoneyInvalid, c)
+		}
+	})
+
+	t.Run("error - overflow", func(t *testing.T) {
+		cases := []string{"9223372036854775808", "-9223372036854775809", "92233720368547758.08"}
+		for _, c := range cases {
+			// act
+			_, err := internal.ParseMoney(c, "")
+
+			// assert
+			require.ErrorIs(t, err, internal.ErrMoneyOverflow, c)
+		}
+	})
+
+	t.Run("currency", func(t *testing.T) {
+		// act
+		eur := money(t, "1.00", "EUR")
+		usd := money(t, "1.00", "USD")
+
+		// assert
+		require.Equal(t, "EUR", eur.Currency())
+		require.Equal(t, internal.Money{}.Currency(), usd.Currency())
+		require.True(t, usd.Equal(money(t, "1", "")))
+		require.False(t, eur.Equal(usd))
+	})
+}
+
+// Tests for Money.Rescale method
+func TestMoney_Rescale(t *testing.T) {
+	t.Run("rounding", func(t *testing.T) {
+		cases := []struct {
+			input    string
+			mode     internal.RoundingMode
+			expected string
+		}{
+			{"2.345", internal.RoundHalfEven, "2.34"},
+			{"2.355", internal.RoundHalfEven, "2.36"},
+			{"2.346", internal.RoundHalfEven, "2.35"},
+			{"-2.345", internal.RoundHalfEven, "-2.34"},
+			{"-2.355", internal.RoundHalfEven, "-2.36"},
+			{"-2.344", internal.RoundHalfEven, "-2.34"},
+			{"2.345", internal.RoundHalfUp, "2.35"},
+			{"-2.345", internal.RoundHalfUp, "-2.35"},
+			{"2.344", internal.RoundHalfUp, "2.34"},
+			{"2.349", internal.RoundDown, "2.34"},
+			{"-2.349", internal.RoundDown, "-2.34"},
+			{"2.3", internal.RoundHalfEven, "2.30"},
+		}
+		for _, c := range cases {
+			// act
+			r, err := money(t, c.input, "").Rescale(2, c.mode)
+
+			// assert
+			require.NoError(t, err, c.input)
+			require.Equal(t, c.expected, r.String(), c.input)
+		}
+	})
+
+	t.Run("error - overflow", func(t *testing.T) {
+		// act
+		_, err := money(t, "92233720368547759", "").Rescale(2, internal.RoundHalfEven)
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrMoneyOverflow)
+	})
+
+	t.Run("error - invalid scale", func(t *testing.T) {
+		// act
+		_, err := money(t, "1", "").Rescale(10, internal.RoundHalfEven)
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrMoneyInvalid)
+	})
+}
+
+// Tests for Money.Add, Money.Sub and Money.Mul methods
+func TestMoney_Arithmetic(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// arrange
+		a := money(t, "0.1", "")
+		b := money(t, "0.20", "")
+
+		// act
+		sum, err1 := a.Add(b)
+		diff, err2 := a.Sub(b)
+		prod, err3 := b.Mul(-3)
+
+		// assert
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		require.NoError(t, err3)
+		require.Equal(t, "0.30", sum.String())
+		require.Equal(t, "-0.10", diff.String())
+		require.Equal(t, "-0.60", prod.String())
+	})
+
+	t.Run("error - overflow", func(t *testing.T) {
+		// arrange
+		maxM := internal.NewMoney(math.MaxInt64, 0, "")
+		minM := internal.NewMoney(math.MinInt64, 0, "")
+		one := internal.NewMoney(1, 0, "")
+
+		cases := []struct {
+			name string
+			fn   func() (internal.Money, error)
+		}{
+			{"max + 1", func() (internal.Money, error) { return maxM.Add(one) }},
+			{"min + -1", func() (internal.Money, error) { return minM.Add(internal.NewMoney(-1, 0, "")) }},
+			{"min - 1", func() (internal.Money, error) { return minM.Sub(one) }},
+			{"max - -1", func() (internal.Money, error) { return maxM.Sub(internal.NewMoney(-1, 0, "")) }},
+			{"0 - min", func() (internal.Money, error) { return internal.Money{}.Sub(minM) }},
+			{"max * 2", func() (internal.Money, error) { return maxM.Mul(2) }},
+			{"min * -1", func() (internal.Money, error) { return minM.Mul(-1) }},
+			{"-1 * min", func() (internal.Money, error) { return internal.NewMoney(-1, 0, "").Mul(math.MinInt64) }},
+		}
+		for _, c := range cases {
+			// act
+			r, err := c.fn()
+
+			// assert
+			require.ErrorIs(t, err, internal.ErrMoneyOverflow, c.name)
+			require.Equal(t, internal.Money{}, r, c.name)
+		}
+	})
+
+	t.Run("success - at the bounds", func(t *testing.T) {
+		// arrange
+		maxM := internal.NewMoney(math.MaxInt64, 0, "")
+		minM := internal.NewMoney(math.MinInt64, 0, "")
+
+		// act
+		sum, err1 := maxM.Add(minM)
+		diff, err2 := minM.Sub(internal.NewMoney(-1, 0, ""))
+		prod, err3 := minM.Mul(1)
+
+		// assert
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		require.NoError(t, err3)
+		require.Equal(t, "-1", sum.String())
+		require.Equal(t, "-9223372036854775807", diff.String())
+		require.Equal(t, "-9223372036854775808", prod.String())
+	})
+
+	t.Run("error - currency mismatch", func(t *testing.T) {
+		// act
+		_, err := money(t, "1", "EUR").Add(money(t, "1", "USD"))
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrMoneyCurrency)
+	})
+}
+
+// Tests for Money.MulRat and Money.Convert methods
+func TestMoney_MulRat(t *testing.T) {
+	t.Run("rounding", func(t *testing.T) {
+		cases := []struct {
+			input    string
+			rate     string
+			mode     internal.RoundingMode
+			expected string
+		}{
+			{"10.05", "0.925", internal.RoundHalfEven, "9.30"},
+			{"1.00", "1/3", internal.RoundHalfEven, "0.33"},
+			{"2.00", "1/3", internal.RoundHalfUp, "0.67"},
+			{"2.00", "1/3", internal.RoundDown, "0.66"},
+			{"0.05", "0.5", internal.RoundHalfEven, "0.02"},
+			{"0.07", "0.5", internal.RoundHalfEven, "0.04"},
+			{"0.05", "0.5", internal.RoundHalfUp, "0.03"},
+			{"-0.05", "0.5", internal.RoundHalfEven, "-0.02"},
+			{"-0.07", "0.5", internal.RoundHalfEven, "-0.04"},
+			{"-0.05", "0.5", internal.RoundHalfUp, "-0.03"},
+			{"-2.00", "1/3", internal.RoundDown, "-0.66"},
+		}
+		for _, c := range cases {
+			// arrange
+			rate, ok := new(big.Rat).SetString(c.rate)
+			require.True(t, ok)
+
+			// act
+			r, err := money(t, c.input, "").MulRat(rate, 2, c.mode)
+
+			// assert
+			require.NoError(t, err, c.input)
+			require.Equal(t, c.expected, r.String(), c.input+" * "+c.rate)
+		}
+	})
+
+	t.Run("error - overflow", func(t *testing.T) {
+		// act
+		_, err := internal.NewMoney(math.MaxInt64, 0, "").MulRat(big.NewRat(2, 1), 0, internal.RoundHalfEven)
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrMoneyOverflow)
+	})
+
+	t.Run("convert", func(t *testing.T) {
+		// act
+		r, err := money(t, "10.05", "").Convert("EUR", big.NewRat(925, 1000), 2, internal.RoundHalfEven)
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, "9.30", r.String())
+		require.Equal(t, "EUR", r.Currency())
+	})
+}
+
+// Tests for Money.String method
+func TestMoney_String(t *testing.T) {
+	cases := []struct {
+		m        internal.Money
+		expected string
+	}{
+		{internal.Money{}, "0"},
+		{internal.NewMoney(5, 3, ""), "0.005"},
+		{internal.NewMoney(-5, 3, ""), "-0.005"},
+		{internal.NewMoney(math.MinInt64, 0, ""), "-9223372036854775808"},
+		{internal.NewMoney(math.MinInt64, 2, ""), "-92233720368547758.08"},
+		{internal.NewMoney(math.MaxInt64, 9, ""), "9223372036.854775807"},
+	}
+	for _, c := range cases {
+		// act
+		s := c.m.String()
+
+		// assert
+		require.Equal(t, c.expected, s)
+	}
+}
+
+// Tests for Money.Scan and Money.Value methods
+func TestMoney_Scan(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		cases := []struct {
+			src      any
+			expected string
+		}{
+			{[]byte("12.30"), "12.30"},
+			{"-0.01", "-0.01"},
+			{int64(42), "42"},
+			{float64(10.5), "10.5"},
+		}
+		for _, c := range cases {
+			// act
+			m := internal.NewMoney(0, 0, "EUR")
+			err := m.Scan(c.src)
+
+			// assert
+			require.NoError(t, err, c.src)
+			require.Equal(t, c.expected, m.String(), c.src)
+			require.Equal(t, "EUR", m.Currency(), c.src)
+		}
+	})
+
+	t.Run("error - unsupported type", func(t *testing.T) {
+		// act
+		var m internal.Money
+		err := m.Scan(nil)
+
+		// assert
+		require.ErrorIs(t, err, internal.ErrMoneyInvalid)
+	})
+
+	t.Run("value", func(t *testing.T) {
+		// act
+		v, err := money(t, "-1.50", "").Value()
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, "-1.50", v)
+	})
+}
+
+// Tests for Money.MarshalJSON and Money.UnmarshalJSON methods
+func TestMoney_JSON(t *testing.T) {
+	t.Run("marshal", func(t *testing.T) {
+		// act
+		b, err := json.Marshal(money(t, "0.30", ""))
+
+		// assert
+		require.NoError(t, err)
+		require.Equal(t, `"0.30"`, string(b))
+	})
+
+	t.Run("unmarshal", func(t *testing.T) {
+		cases := []struct {
+			input    string
+			expected string
+		}{
+			{`"12.30"`, "12.30"},
+			{`12.30`, "12.30"},
+			{`-0.1`, "-0.1"},
+			{`7`, "7"},
+		}
+		for _, c := range cases {
+			// act
+			m := internal.NewMoney(0, 0, "EUR")
+			err := json.Unmarshal([]byte(c.input), &m)
+
+			// assert
+			require.NoError(t, err, c.input)
+			require.Equal(t, c.expected, m.String(), c.input)
+			require.Equal(t, "EUR", m.Currency(), c.input)
+		}
+	})
+
+	t.Run("error - unmarshal", func(t *testing.T) {
+		cases := []string{`"abc"`, `1e3`, `true`, `"1.2.3"`, `null`}
+		for _, c := range cases {
+			// act
+			var m internal.Money
+			err := json.Unmarshal([]byte(c), &m)
+
+			// assert
+			require.Error(t, err, c)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package internal
+
+import "time"
+
+// PriceChange is an struct that represents a change of the price of a product scheduled at a time
+type PriceChange struct {
+	// ID is the unique identifier of the change
+	ID int
+	// ProductID is the id of the product whose price changes
+	ProductID int
+	// Price is the price of the product from the time the change is effective
+	Price Money
+	// EffectiveAt is the time the change takes effect
+	EffectiveAt time.Time
+	// AppliedAt is the time the change was written to the product, or superseded by a manual change, nil while it is pending
+	AppliedAt *time.Time
+	// Actor is the name of who scheduled the change
+	Actor string
+	// CreatedAt is the time the change was scheduled
+	CreatedAt time.Time
+}
@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrPriceChangeNotFound is an error that will be returned when a pending price change is not found
+	ErrPriceChangeNotFound = errors.New("repository: price change not found")
+)
+
+// RepositoryPriceChanges is an interface that represents a repository of scheduled price changes
+// - a change is pending until it is applied to its product, from its effective time the price of the product is the
+// price of its latest pending change due, even before it is applied
+// - every method stops when ctx is done, returning context.DeadlineExceeded when its deadline expires
+type RepositoryPriceChanges interface {
+	// Schedule schedules a change of the price of a product, the products in the trash are not found
+	Schedule(ctx context.Context, c *PriceChange) (err error)
+	// GetAll returns the changes of the price of a product, applied and pending, ordered by effective time
+	GetAll(ctx context.Context, productID int) (c []PriceChange, err error)
+	// Cancel deletes a pending change of the price of a product
+	Cancel(ctx context.Context, productID, id int) (err error)
+	// Effective returns the price of the latest pending change due at the given time of each of the products that have one
+	Effective(ctx context.Context, productIDs []int, at time.Time) (prices map[int]Money, err error)
+	// Apply writes the pending changes due at now to their products and returns the number of products changed
+	Apply(ctx context.Context, now time.Time) (n int, err error)
+}
@@ -16,6 +16,12 @@ type Product struct {
 	IsPublished bool
 	// Expiration is the expiration date of the product
 	Expiration time.Time
-	// Price is the price of the product
-	Price float64
+	// Price is the price of the product, in DefaultCurrency with PriceScale decimal digits
+	Price Money
+	// WarehouseID is the id of the warehouse the product is stored in, nil if it is not assigned to one
+	WarehouseID *int
+	// Version is the number of the revision of the product, it increases with every change
+	Version int
+	// DeletedAt is the time the product was moved to the trash, nil if it is not in the trash
+	DeletedAt *time.Time
 }
@@ -0,0 +1,38 @@
+package internal
+
+import "time"
+
+const (
+	// AuditCreate is the operation of a product created
+	AuditCreate = "create"
+	// AuditUpdate is the operation of a product updated
+	AuditUpdate = "update"
+	// AuditDelete is the operation of a product moved to the trash
+	AuditDelete = "delete"
+	// AuditRestore is the operation of a product moved out of the trash
+	AuditRestore = "restore"
+	// AuditPurge is the operation of a product deleted permanently
+	AuditPurge = "purge"
+	// AuditExpire is the operation of a product unpublished because its expiration passed
+	AuditExpire = "expire"
+)
+
+// ProductAudit is an struct that represents a change of a product
+type ProductAudit struct {
+	// ID is the unique identifier of the change
+	ID int
+	// ProductID is the id of the product changed
+	ProductID int
+	// Version is the version of the product after the change, or before it for a purge
+	Version int
+	// Operation is the kind of change, one of the Audit constants
+	Operation string
+	// Actor is the name of who made the change
+	Actor string
+	// ChangedAt is the time of the change
+	ChangedAt time.Time
+	// Before is the product before the change, nil for a create
+	Before *Product
+	// After is the product after the change, nil for a purge
+	After *Product
+}
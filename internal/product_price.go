@@ -0,0 +1,15 @@
+package internal
+
+import "time"
+
+// ProductPrice is an struct that represents the price of a product set explicitly in a currency other than DefaultCurrency
+type ProductPrice struct {
+	// ProductID is the id of the product
+	ProductID int
+	// Price is the price of the product, in its currency
+	Price Money
+	// Actor is the name of who set the price
+	Actor string
+	// UpdatedAt is the time the price was set
+	UpdatedAt time.Time
+}
@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrProductPriceNotFound is an error that will be returned when a product has no price set in a currency
+	ErrProductPriceNotFound = errors.New("repository: product price not found")
+)
+
+// RepositoryProductPrices is an interface that represents a repository of the prices of the products in other currencies
+// - a product without a price in a currency is priced by converting its price with the exchange rate into it
+// - every method stops when ctx is done, returning context.DeadlineExceeded when its deadline expires
+type RepositoryProductPrices interface {
+	// GetAll returns the prices of a product in other currencies, ordered by currency
+	GetAll(ctx context.Context, productID int) (p []ProductPrice, err error)
+	// In returns the price in a currency of each of the products that have one
+	In(ctx context.Context, productIDs []int, currency string) (prices map[int]Money, err error)
+	// Set sets the price of a product in the currency of p.Price, the products in the trash are not found
+	Set(ctx context.Context, p *ProductPrice) (err error)
+	// Delete deletes the price of a product in a currency
+	Delete(ctx context.Context, productID int, currency string) (err error)
+}
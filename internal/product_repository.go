@@ -1,6 +1,12 @@
 package internal
 
-import "errors"
+import (
+	"app/platform/filter"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	// ErrProductNotFound is an error that will be returned when a product is not found
@@ -9,16 +15,172 @@ var (
 	ErrProductNotUnique = errors.New("repository: product not unique")
 	// ErrProductRelation is an error that will be returned when a product relation fails
 	ErrProductRelation = errors.New("repository: product relation error")
+	// ErrProductBatchLimit is an error that will be returned when a batch operation would affect more products than allowed
+	ErrProductBatchLimit = errors.New("repository: product batch limit exceeded")
+	// ErrProductVersion is an error that will be returned when a product was changed since the version being updated
+	ErrProductVersion = errors.New("repository: product version mismatch")
 )
 
+// FieldError is an error of a repository caused by the value of a field
+type FieldError struct {
+	// Field is the name of the field, empty if it is unknown
+	Field string
+	// Err is the repository error, such as ErrProductNotUnique
+	Err error
+	// Cause is the error of the underlying storage
+	Cause error
+}
+
+// Error returns the message of the repository error followed by the field
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Err, e.Field)
+}
+
+// Unwrap returns the repository error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ProductQuery is an struct that represents the options to list products
+type ProductQuery struct {
+	// Limit is the maximum number of products to return
+	Limit int
+	// Offset is the number of products to skip
+	Offset int
+	// Filter is the expression the products must match, nil matches every product
+	Filter filter.Expr
+	// Sort is the list of criteria to order the products by, ties are always broken by id
+	Sort []ProductSort
+	// Trashed reports whether to list the products in the trash instead of the active ones
+	Trashed bool
+	// After is the position of the last product seen, if set the listing uses keyset pagination and Offset is ignored
+	After *ProductCursor
+	// AsOf is the instant to list the products at, if set they are reconstructed from their history
+	AsOf *time.Time
+}
+
+// ProductSort is an struct that represents a criterion to order a product listing
+type ProductSort struct {
+	// Field is the name of the field to order by
+	Field string
+	// Desc reports whether the order is descending
+	Desc bool
+}
+
+// ProductCursor is an struct that represents the position of the last product seen in a keyset listing
+type ProductCursor struct {
+	// ID is the id of the last product seen
+	ID int
+	// Values are the values of the sort fields of the last product seen, in the same order as ProductQuery.Sort
+	Values []any
+}
+
+// ProductSearch is an struct that represents a full-text search of products
+type ProductSearch struct {
+	// Query is the text to search for
+	Query string
+	// Boolean reports whether Query uses the boolean syntax: +required -excluded prefix* "phrase"
+	Boolean bool
+	// Limit is the maximum number of products to return
+	Limit int
+}
+
+// ProductSearchResult is an struct that represents a product found by a search
+type ProductSearchResult struct {
+	// Product is the product found
+	Product Product
+	// Score is the relevance of the product for the search, higher is more relevant
+	Score float64
+}
+
+// ProductSuggestion is an struct that represents the value of a product field suggested for a prefix
+type ProductSuggestion struct {
+	// ID is the id of the product
+	ID int
+	// Value is the value of the field
+	Value string
+}
+
+// ProductSelector is an struct that represents the products affected by a batch operation
+// - either IDs or Filter is set, when both are set a product must match both
+type ProductSelector struct {
+	// IDs is the list of ids of the products
+	IDs []int
+	// Filter is the expression the products must match
+	Filter filter.Expr
+}
+
+// ProductPatch is an struct that represents the changes of a batch update, nil fields are left unchanged
+// - the quantity is not part of it, it only changes through RepositoryStockMovements
+type ProductPatch struct {
+	Name        *string
+	CodeValue   *string
+	IsPublished *bool
+	Expiration  *time.Time
+	Price       *Money
+	WarehouseID *int
+}
+
+// BatchOptions is an struct that represents the options of a batch operation
+type BatchOptions struct {
+	// MaxAffected is the maximum number of products the operation may affect, 0 means no limit
+	MaxAffected int
+	// DryRun reports whether the operation only counts the products it would affect
+	DryRun bool
+}
+
 // RepositoryProducts is an interface that represents a product repository
+// - every method stops when ctx is done, returning context.DeadlineExceeded when its deadline expires
+// - every change of a product is recorded as a ProductAudit along with it
 type RepositoryProducts interface {
-	// GetOne returns a product by id
-	GetOne(id int) (p Product, err error)
-	// Store stores a product
-	Store(p *Product) (err error)
-	// Update updates a product
-	Update(p *Product) (err error)
-	// Delete deletes a product by id
-	Delete(id int) (err error)
+	// GetOne returns a product by id, the products in the trash are not found
+	GetOne(ctx context.Context, id int) (p Product, err error)
+	// GetOneAsOf returns a product by id as it was at the given instant, reconstructed from its history
+	// - products in the trash or purged at that instant are not found, as are products with no history before it
+	GetOneAsOf(ctx context.Context, id int, at time.Time) (p Product, err error)
+	// GetAll returns a page of products and the total number of products, not counted (0) for pages after a cursor
+	GetAll(ctx context.Context, q ProductQuery) (p []Product, total int, err error)
+	// Export calls fn with every product matching the filter of q, in its sort order, as they are read
+	// - Limit, Offset and After are ignored, the export stops when ctx is done or fn returns an error
+	Export(ctx context.Context, q ProductQuery, fn func(p Product) error) (err error)
+	// Search returns the products matching a full-text search ranked by relevance
+	Search(ctx context.Context, s ProductSearch) (r []ProductSearchResult, err error)
+	// Suggest returns the values of a field (name or code_value) starting with prefix case insensitively, ordered by the bytes of the value
+	Suggest(ctx context.Context, field string, prefix string, limit int) (s []ProductSuggestion, err error)
+	// Store stores a product, its quantity is appended to the stock ledger as the initial movement
+	Store(ctx context.Context, p *Product) (err error)
+	// StoreBatch stores products in a single transaction, errs holds the error of each product (nil if stored)
+	// - atomic: the first error rolls every product back, otherwise the products that fail are skipped
+	StoreBatch(ctx context.Context, p []Product, atomic bool) (errs []error, err error)
+	// Update updates a product if its version is still p.Version, incrementing it
+	// - ErrProductNotFound is returned if it does not exist, ErrProductVersion if it has another version
+	// - the quantity is left as it is, it only changes through RepositoryStockMovements
+	// - a new price supersedes the scheduled price changes already due
+	Update(ctx context.Context, p *Product) (err error)
+	// AdjustQuantity adds delta to the quantity of a product in a single guarded statement and returns the product adjusted
+	// - it fails with ErrStockInsufficient if the quantity would drop below the quantity reserved, the adjust is appended to the stock ledger
+	AdjustQuantity(ctx context.Context, id int, delta int) (p Product, err error)
+	// UpdateBatch applies a patch to the selected products in a single transaction and returns the number of products affected
+	// - ErrProductBatchLimit is returned, with the number of products selected, when it exceeds o.MaxAffected
+	UpdateBatch(ctx context.Context, s ProductSelector, patch ProductPatch, o BatchOptions) (n int, err error)
+	// Delete moves a product to the trash, ErrProductNotFound is returned if it does not exist
+	Delete(ctx context.Context, id int) (err error)
+	// DeleteBatch moves the selected products to the trash in a single transaction and returns the number of products affected
+	// - ErrProductBatchLimit is returned, with the number of products selected, when it exceeds o.MaxAffected
+	DeleteBatch(ctx context.Context, s ProductSelector, o BatchOptions) (n int, err error)
+	// Restore moves a product out of the trash, ErrProductNotFound is returned if it is not in the trash
+	// - the code value is only unique among the products out of the trash, ErrProductNotUnique is returned if another
+	// product took it meanwhile
+	Restore(ctx context.Context, id int) (err error)
+	// Purge deletes a product permanently, whether it is in the trash or not
+	Purge(ctx context.Context, id int) (err error)
+	// UnpublishExpired unpublishes the published products whose expiration is before the date of at and returns the number of
+	// products unpublished, each change is recorded with the operation AuditExpire
+	// - it may stop at a batch of them, so it is meant to be called repeatedly, such as by a scheduled job
+	UnpublishExpired(ctx context.Context, at time.Time) (n int, err error)
+	// History returns a page of the changes of a product, newest first, and the total number of changes
+	History(ctx context.Context, id int, limit, offset int) (a []ProductAudit, total int, err error)
 }
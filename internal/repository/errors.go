@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"app/internal"
+	"errors"
+	"regexp"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	// mysqlErrDuplicateEntry is the number of the error of a duplicated unique key
+	mysqlErrDuplicateEntry = 1062
+	// mysqlErrRowIsReferenced is the number of the error of deleting or updating a row referenced by a foreign key
+	mysqlErrRowIsReferenced = 1451
+	// mysqlErrNoReferencedRow is the number of the error of referencing a row that does not exist through a foreign key
+	mysqlErrNoReferencedRow = 1452
+)
+
+var (
+	// uniqueKeyFields maps the unique keys of the tables to the fields they cover
+	// - the code value of a product is only unique among the products out of the trash
+	uniqueKeyFields = map[string]string{
+		"idx_products_code_value": "code_value",
+		"idx_warehouses_code":     "code",
+	}
+	// reDuplicateKey matches the key of a duplicate entry error, with or without the table name (MySQL 8 / 5.7)
+	reDuplicateKey = regexp.MustCompile("for key '(?:[^'.]+\\.)?([^']+)'")
+	// reForeignKey matches the column of a foreign key error
+	reForeignKey = regexp.MustCompile("FOREIGN KEY \\(`([^`]+)`\\)")
+)
+
+// translateError translates the errors of the mysql driver into the errors of internal.RepositoryProducts
+// - the field that caused the error is attached as an internal.FieldError when it can be told from the message
+func translateError(err error) error {
+	return translateErrorTo(err, internal.ErrProductNotUnique, internal.ErrProductRelation)
+}
+
+// translateErrorTo translates the errors of the mysql driver into the given errors of a repository
+// - notUnique for a duplicated unique key, relation for a foreign key that fails
+func translateErrorTo(err error, notUnique, relation error) error {
+	var me *mysql.MySQLError
+	if !errors.As(err, &me) {
+		return err
+	}
+
+	switch me.Number {
+	case mysqlErrDuplicateEntry:
+		field := ""
+		if m := reDuplicateKey.FindStringSubmatch(me.Message); m != nil {
+			field = uniqueKeyFields[m[1]]
+		}
+		return &internal.FieldError{Field: field, Err: notUnique, Cause: err}
+	case mysqlErrRowIsReferenced, mysqlErrNoReferencedRow:
+		field := ""
+		if m := reForeignKey.FindStringSubmatch(me.Message); m != nil {
+			field = m[1]
+		}
+		return &internal.FieldError{Field: field, Err: relation, Cause: err}
+	}
+	return err
+}
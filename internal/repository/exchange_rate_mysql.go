@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"app/internal"
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// NewExchangeRatesMySQL returns a new instance of ExchangeRatesMySQL
+// - timeout bounds every query, 0 means no timeout other than the one of the context
+func NewExchangeRatesMySQL(db *sql.DB, timeout time.Duration) *ExchangeRatesMySQL {
+	return &ExchangeRatesMySQL{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+// ExchangeRatesMySQL is a struct that represents a repository of the exchange rates from internal.DefaultCurrency
+type ExchangeRatesMySQL struct {
+	// db is the database connection
+	db *sql.DB
+	// timeout is the maximum duration of a query
+	timeout time.Duration
+}
+
+// exchangeRateColumns is the list of the columns of an exchange rate, in the order scanExchangeRate reads them
+const exchangeRateColumns = "`currency`, `rate`, `actor`, `updated_at`"
+
+// scanExchangeRate scans a row of exchangeRateColumns into an exchange rate
+func scanExchangeRate(s scanner) (er internal.ExchangeRate, err error) {
+	var rate string
+	if err = s.Scan(&er.Currency, &rate, &er.Actor, &er.UpdatedAt); err != nil {
+		return
+	}
+	var ok bool
+	if er.Rate, ok = new(big.Rat).SetString(rate); !ok {
+		err = fmt.Errorf("repository: invalid exchange rate %q", rate)
+	}
+	return
+}
+
+// GetOne returns the exchange rate into a currency
+func (r *ExchangeRatesMySQL) GetOne(ctx context.Context, currency string) (er internal.ExchangeRate, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	row := r.db.QueryRowContext(
+		ctx,
+		"SELECT "+exchangeRateColumns+" FROM `exchange_rates` WHERE `currency` = ?",
+		currency,
+	)
+	if err = row.Err(); err != nil {
+		return
+	}
+
+	// scan the row into the exchange rate
+	er, err = scanExchangeRate(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = internal.ErrExchangeRateNotFound
+		}
+		return
+	}
+
+	return
+}
+
+// GetAll returns every exchange rate, ordered by currency
+func (r *ExchangeRatesMySQL) GetAll(ctx context.Context) (er []internal.ExchangeRate, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, "SELECT "+exchangeRateColumns+" FROM `exchange_rates` ORDER BY `currency`")
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the exchange rates
+	er = make([]internal.ExchangeRate, 0)
+	for rows.Next() {
+		var e internal.ExchangeRate
+		if e, err = scanExchangeRate(rows); err != nil {
+			return
+		}
+		er = append(er, e)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// Replace replaces the set of exchange rates in a single transaction, the currencies not in er are removed
+// - the rates are rounded to internal.ExchangeRateScale decimal digits
+func (r *ExchangeRatesMySQL) Replace(ctx context.Context, er []internal.ExchangeRate) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// remove the previous set
+	if _, err = tx.ExecContext(ctx, "DELETE FROM `exchange_rates`"); err != nil {
+		return
+	}
+
+	// insert the new set
+	if len(er) > 0 {
+		actor := actorOf(ctx)
+		now := time.Now().UTC()
+		args := make([]any, 0, 4*len(er))
+		for i := range er {
+			er[i].Actor = actor
+			er[i].UpdatedAt = now
+			args = append(args, er[i].Currency, er[i].Rate.FloatString(internal.ExchangeRateScale), actor, now)
+		}
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO `exchange_rates` ("+exchangeRateColumns+") VALUES (?, ?, ?, ?)" +
+			strings.Repeat(", (?, ?, ?, ?)", len(er)-1),
+			args...,
+		)
+		if err != nil {
+			return
+		}
+	}
+
+	// commit the transaction
+	err = tx.Commit()
+	return
+}
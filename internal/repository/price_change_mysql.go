@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"app/internal"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NewPriceChangesMySQL returns a new instance of PriceChangesMySQL
+// - timeout bounds every query, 0 means no timeout other than the one of the context
+func NewPriceChangesMySQL(db *sql.DB, timeout time.Duration) *PriceChangesMySQL {
+	return &PriceChangesMySQL{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+// PriceChangesMySQL is a struct that represents a repository of scheduled price changes
+type PriceChangesMySQL struct {
+	// db is the database connection
+	db *sql.DB
+	// timeout is the maximum duration of a query
+	timeout time.Duration
+}
+
+// applyBatchSize is the maximum number of products whose price is changed by a call of Apply
+const applyBatchSize = 500
+
+// supersedePrices marks the pending price changes due of the products as applied within tx
+// - a price set by hand replaces the scheduled ones already due, so they are not applied over it later
+func supersedePrices(ctx context.Context, tx *sql.Tx, ids ...int) (err error) {
+	if len(ids) == 0 {
+		return
+	}
+	now := time.Now().UTC()
+	args := []any{now, now}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE `price_changes` SET `applied_at` = ? WHERE `applied_at` IS NULL AND `effective_at` <= ? " +
+		"AND `product_id` IN (?" + strings.Repeat(", ?", len(ids)-1) + ")",
+		args...,
+	)
+	return
+}
+
+// Schedule schedules a change of the price of a product
+func (r *PriceChangesMySQL) Schedule(ctx context.Context, c *internal.PriceChange) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the product, so it is not purged meanwhile
+	if _, err = lockProduct(ctx, tx, c.ProductID, notDeleted); err != nil {
+		return
+	}
+
+	// execute the query
+	c.Actor = actorOf(ctx)
+	c.CreatedAt = time.Now().UTC()
+	c.AppliedAt = nil
+	result, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO `price_changes` (`product_id`, `price`, `effective_at`, `actor`, `created_at`) VALUES (?, ?, ?, ?, ?)",
+		c.ProductID, c.Price, c.EffectiveAt.UTC(), c.Actor, c.CreatedAt,
+	)
+	if err != nil {
+		err = translateError(err)
+		return
+	}
+
+	// get the last inserted id
+	id, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+	c.ID = int(id)
+
+	// commit the transaction
+	err = tx.Commit()
+	return
+}
+
+// GetAll returns the changes of the price of a product, applied and pending, ordered by effective time
+func (r *PriceChangesMySQL) GetAll(ctx context.Context, productID int) (c []internal.PriceChange, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT `id`, `product_id`, `price`, `effective_at`, `applied_at`, `actor`, `created_at` " +
+		"FROM `price_changes` WHERE `product_id` = ? ORDER BY `effective_at`, `id`",
+		productID,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the changes
+	c = make([]internal.PriceChange, 0)
+	for rows.Next() {
+		var pc internal.PriceChange
+		err = rows.Scan(&pc.ID, &pc.ProductID, &pc.Price, &pc.EffectiveAt, &pc.AppliedAt, &pc.Actor, &pc.CreatedAt)
+		if err != nil {
+			return
+		}
+		c = append(c, pc)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// Cancel deletes a pending change of the price of a product
+func (r *PriceChangesMySQL) Cancel(ctx context.Context, productID, id int) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	result, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM `price_changes` WHERE `id` = ? AND `product_id` = ? AND `applied_at` IS NULL",
+		id, productID,
+	)
+	if err != nil {
+		return
+	}
+
+	// check the change was found
+	n, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		err = internal.ErrPriceChangeNotFound
+		return
+	}
+
+	return
+}
+
+// Effective returns the price of the latest pending change due at the given time of each of the products that have one
+func (r *PriceChangesMySQL) Effective(ctx context.Context, productIDs []int, at time.Time) (prices map[int]internal.Money, err error) {
+	prices = make(map[int]internal.Money)
+	if len(productIDs) == 0 {
+		return
+	}
+
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	// - changes due at the same time are applied in the order they were scheduled, so the last one wins
+	args := []any{at.UTC()}
+	for _, id := range productIDs {
+		args = append(args, id)
+	}
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT `product_id`, `price` FROM `price_changes` WHERE `applied_at` IS NULL AND `effective_at` <= ? " +
+		"AND `product_id` IN (?" + strings.Repeat(", ?", len(productIDs)-1) + ") ORDER BY `effective_at`, `id`",
+		args...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the prices
+	for rows.Next() {
+		var id int
+		var price internal.Money
+		if err = rows.Scan(&id, &price); err != nil {
+			return
+		}
+		prices[id] = price
+	}
+	err = rows.Err()
+
+	return
+}
+
+// Apply writes the pending changes due at now to their products and returns the number of products changed
+// - each product is changed in its own transaction, along with its audit row
+// - at most applyBatchSize products are changed per call, the rest are left to the next one
+// - a product that fails is skipped, so it does not hold back the others, the errors are returned joined
+func (r *PriceChangesMySQL) Apply(ctx context.Context, now time.Time) (n int, err error) {
+	// find the products with changes due
+	ids, err := r.due(ctx, now)
+	if err != nil {
+		return
+	}
+
+	// apply the changes of each product
+	var errs []error
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		applied, e := r.apply(ctx, id, now)
+		if e != nil {
+			errs = append(errs, fmt.Errorf("product %d: %w", id, e))
+			continue
+		}
+		if applied {
+			n++
+		}
+	}
+	err = errors.Join(errs...)
+	return
+}
+
+// due returns the ids of the products with pending changes due at now
+func (r *PriceChangesMySQL) due(ctx context.Context, now time.Time) (ids []int, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT DISTINCT `product_id` FROM `price_changes` WHERE `applied_at` IS NULL AND `effective_at` <= ? LIMIT ?",
+		now.UTC(), applyBatchSize,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the ids
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			return
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// apply writes the latest pending change due at now to a product, applied reports whether there was one
+func (r *PriceChangesMySQL) apply(ctx context.Context, id int, now time.Time) (applied bool, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the product, then its changes due
+	// - a product in the trash is changed as well, so it is restored with its scheduled price
+	before, err := lockProduct(ctx, tx, id, "TRUE")
+	if err != nil {
+		return
+	}
+	var price internal.Money
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT `price` FROM `price_changes` WHERE `product_id` = ? AND `applied_at` IS NULL AND `effective_at` <= ? " +
+		"ORDER BY `effective_at` DESC, `id` DESC LIMIT 1 FOR UPDATE",
+		id, now.UTC(),
+	).Scan(&price)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// - applied or superseded meanwhile
+			err = nil
+		}
+		return
+	}
+
+	// execute the query
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE `products` SET `price` = ?, `version` = `version` + 1 WHERE `id` = ?",
+		price, id,
+	)
+	if err != nil {
+		err = translateError(err)
+		return
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE `price_changes` SET `applied_at` = ? WHERE `product_id` = ? AND `applied_at` IS NULL AND `effective_at` <= ?",
+		now.UTC(), id, now.UTC(),
+	)
+	if err != nil {
+		return
+	}
+
+	// record the change
+	after := before
+	after.Price = price
+	after.Version++
+	if err = audit(ctx, tx, internal.AuditUpdate, &before, &after); err != nil {
+		return
+	}
+
+	// commit the transaction
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	applied = true
+
+	return
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"app/internal"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// productSnapshot is a struct that represents a product stored in an audit row
+type productSnapshot struct {
+	ID          int            `json:"id"`
+	Name        string         `json:"name"`
+	Quantity    int            `json:"quantity"`
+	CodeValue   string         `json:"code_value"`
+	IsPublished bool           `json:"is_published"`
+	Expiration  string         `json:"expiration"`
+	Price       internal.Money `json:"price"`
+	WarehouseID *int           `json:"warehouse_id"`
+	Version     int            `json:"version"`
+	DeletedAt   *string        `json:"deleted_at"`
+}
+
+// marshalSnapshot returns the json of a product stored in an audit row, nil for a nil product
+// - dates are formatted as MySQL reads them, so snapshots can be queried with JSON_TABLE
+func marshalSnapshot(p *internal.Product) (b []byte, err error) {
+	if p == nil {
+		return
+	}
+	var deletedAt *string
+	if p.DeletedAt != nil {
+		d := p.DeletedAt.UTC().Format(time.DateTime)
+		deletedAt = &d
+	}
+	return json.Marshal(productSnapshot{
+		ID:          p.ID,
+		Name:        p.Name,
+		Quantity:    p.Quantity,
+		CodeValue:   p.CodeValue,
+		IsPublished: p.IsPublished,
+		Expiration:  p.Expiration.Format(time.DateOnly),
+		Price:       p.Price,
+		WarehouseID: p.WarehouseID,
+		Version:     p.Version,
+		DeletedAt:   deletedAt,
+	})
+}
+
+// unmarshalSnapshot returns the product of the json of an audit row, nil for a null json
+func unmarshalSnapshot(b []byte) (p *internal.Product, err error) {
+	if b == nil {
+		return
+	}
+	var s productSnapshot
+	if err = json.Unmarshal(b, &s); err != nil {
+		return
+	}
+	exp, err := time.Parse(time.DateOnly, s.Expiration)
+	if err != nil {
+		return
+	}
+	var deletedAt *time.Time
+	if s.DeletedAt != nil {
+		var d time.Time
+		if d, err = time.Parse(time.DateTime, *s.DeletedAt); err != nil {
+			return
+		}
+		deletedAt = &d
+	}
+	p = &internal.Product{
+		ID:          s.ID,
+		Name:        s.Name,
+		Quantity:    s.Quantity,
+		CodeValue:   s.CodeValue,
+		IsPublished: s.IsPublished,
+		Expiration:  exp,
+		Price:       s.Price,
+		WarehouseID: s.WarehouseID,
+		Version:     s.Version,
+		DeletedAt:   deletedAt,
+	}
+	return
+}
+
+// actorOf returns the name of the actor bound to ctx, internal.ActorAnonymous if it is unknown
+func actorOf(ctx context.Context) string {
+	if actor := internal.ActorOf(ctx); actor != "" {
+		return actor
+	}
+	return internal.ActorAnonymous
+}
+
+// audit records a change of a product within tx, made by the caller bound to ctx
+func audit(ctx context.Context, tx *sql.Tx, operation string, before, after *internal.Product) (err error) {
+	// the product of the change
+	p := after
+	if p == nil {
+		p = before
+	}
+	actor := actorOf(ctx)
+
+	// serialize the snapshots
+	b, err := marshalSnapshot(before)
+	if err != nil {
+		return
+	}
+	a, err := marshalSnapshot(after)
+	if err != nil {
+		return
+	}
+
+	// execute the query
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO `product_audits` (`product_id`, `version`, `operation`, `actor`, `changed_at`, `before`, `after`) "+
+			"VALUES (?, ?, ?, ?, ?, ?, ?)",
+		p.ID, p.Version, operation, actor, time.Now().UTC(), b, a,
+	)
+	return
+}
+
+// History returns a page of the changes of a product, newest first, and the total number of changes
+func (r *ProductsMySQL) History(ctx context.Context, id int, limit, offset int) (a []internal.ProductAudit, total int, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// count the changes
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM `product_audits` WHERE `product_id` = ?", id).Scan(&total)
+	if err != nil {
+		return
+	}
+
+	// execute the query
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT `id`, `product_id`, `version`, `operation`, `actor`, `changed_at`, `before`, `after` "+
+			"FROM `product_audits` WHERE `product_id` = ? ORDER BY `id` DESC LIMIT ? OFFSET ?",
+		id, limit, offset,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the changes
+	a = make([]internal.ProductAudit, 0, limit)
+	for rows.Next() {
+		var pa internal.ProductAudit
+		var before, after []byte
+		err = rows.Scan(&pa.ID, &pa.ProductID, &pa.Version, &pa.Operation, &pa.Actor, &pa.ChangedAt, &before, &after)
+		if err != nil {
+			return
+		}
+		if pa.Before, err = unmarshalSnapshot(before); err != nil {
+			return
+		}
+		if pa.After, err = unmarshalSnapshot(after); err != nil {
+			return
+		}
+		a = append(a, pa)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// productsAsOf is a derived table of the products as they were at an instant, bound by its single parameter
+// - each product is its snapshot after its latest change up to the instant, purged products are left out
+const productsAsOf = "(SELECT s.* FROM `product_audits` a " +
+	"JOIN (SELECT MAX(`id`) AS `id` FROM `product_audits` WHERE `changed_at` <= ? GROUP BY `product_id`) l ON a.`id` = l.`id`, " +
+	"JSON_TABLE(a.`after`, '$' COLUMNS (" +
+	"`id` int PATH '$.id', " +
+	"`name` varchar(255) PATH '$.name', " +
+	"`quantity` int PATH '$.quantity', " +
+	"`code_value` varchar(255) PATH '$.code_value', " +
+	"`is_published` boolean PATH '$.is_published', " +
+	"`expiration` date PATH '$.expiration', " +
+	"`price` decimal(10, 2) PATH '$.price', " +
+	"`warehouse_id` int PATH '$.warehouse_id', " +
+	"`deleted_at` datetime PATH '$.deleted_at', " +
+	"`version` int PATH '$.version'" +
+	")) AS s WHERE a.`after` IS NOT NULL) AS `products`"
+
+// productSource returns the table to select the products from, the current one or the one at asOf if set
+func productSource(asOf *time.Time) (from string, args []any) {
+	if asOf == nil {
+		return "`products`", nil
+	}
+	return productsAsOf, []any{asOf.UTC()}
+}
+
+// GetOneAsOf returns a product by id as it was at the given instant, reconstructed from its history
+func (r *ProductsMySQL) GetOneAsOf(ctx context.Context, id int, at time.Time) (p internal.Product, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	from, args := productSource(&at)
+	row := r.db.QueryRowContext(
+		ctx,
+		"SELECT "+productColumns+" FROM "+from+" WHERE `id` = ? AND "+notDeleted,
+		append(args, id)...,
+	)
+	if err = row.Err(); err != nil {
+		return
+	}
+
+	// scan the row into the product
+	p, err = scanProduct(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = internal.ErrProductNotFound
+		}
+		return
+	}
+
+	return
+}
@@ -2,13 +2,21 @@ package repository
 
 import (
 	"app/internal"
+	"app/platform/filter"
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 )
 
 // NewProductsMySQL returns a new instance of ProductsMySQL
-func NewProductsMySQL(db *sql.DB) *ProductsMySQL {
+// - timeout bounds every query, 0 means no timeout other than the one of the context
+func NewProductsMySQL(db *sql.DB, timeout time.Duration) *ProductsMySQL {
 	return &ProductsMySQL{
-		db: db,
+		db:      db,
+		timeout: timeout,
 	}
 }
 
@@ -16,14 +24,170 @@ func NewProductsMySQL(db *sql.DB) *ProductsMySQL {
 type ProductsMySQL struct {
 	// db is the database connection
 	db *sql.DB
+	// timeout is the maximum duration of a query
+	timeout time.Duration
+}
+
+// productColumns is the list of columns selected for a product
+const productColumns = "`id`, `name`, `quantity`, `code_value`, `is_published`, `expiration`, `price`, `warehouse_id`, `deleted_at`, `version`"
+
+// notDeleted is the condition that excludes the products in the trash
+const notDeleted = "`deleted_at` IS NULL"
+
+// productFields maps the fields of a product that can be queried to their columns
+// - user input is only ever matched against this whitelist, never written into the query
+var productFields = map[string]string{
+	"id":           "`id`",
+	"name":         "`name`",
+	"quantity":     "`quantity`",
+	"code_value":   "`code_value`",
+	"is_published": "`is_published`",
+	"expiration":   "`expiration`",
+	"price":        "`price`",
+}
+
+// filterSQL returns the parameterized condition of a filter expression
+func filterSQL(e filter.Expr) (cond string, args []any, err error) {
+	switch e := e.(type) {
+	case filter.And:
+		cond, args, err = logicalSQL("AND", e.Left, e.Right)
+	case filter.Or:
+		cond, args, err = logicalSQL("OR", e.Left, e.Right)
+	case filter.Not:
+		var c string
+		if c, args, err = filterSQL(e.Expr); err != nil {
+			return
+		}
+		cond = "NOT " + c
+	case filter.Comparison:
+		column, ok := productFields[e.Field]
+		if !ok {
+			err = fmt.Errorf("repository: unknown filter field %q", e.Field)
+			return
+		}
+		switch e.Op {
+		case filter.OpContains:
+			cond = column + " LIKE ?"
+			args = []any{"%" + escapeLike(e.Value.(string)) + "%"}
+		case filter.OpEq, filter.OpNe, filter.OpLt, filter.OpLe, filter.OpGt, filter.OpGe:
+			cond = column + " " + string(e.Op) + " ?"
+			args = []any{e.Value}
+		default:
+			err = fmt.Errorf("repository: unknown filter operator %q", e.Op)
+		}
+	default:
+		err = fmt.Errorf("repository: unknown filter expression %T", e)
+	}
+	return
+}
+
+// logicalSQL returns the parameterized condition joining two filter expressions with op
+func logicalSQL(op string, left, right filter.Expr) (cond string, args []any, err error) {
+	lc, la, err := filterSQL(left)
+	if err != nil {
+		return
+	}
+	rc, ra, err := filterSQL(right)
+	if err != nil {
+		return
+	}
+	cond = "(" + lc + " " + op + " " + rc + ")"
+	args = append(la, ra...)
+	return
+}
+
+// sortKeys returns the sort criteria followed by the id tie-breaker
+func sortKeys(sorts []internal.ProductSort) (keys []internal.ProductSort) {
+	keys = append(keys, sorts...)
+	for _, s := range sorts {
+		if s.Field == "id" {
+			return
+		}
+	}
+	keys = append(keys, internal.ProductSort{Field: "id"})
+	return
+}
+
+// orderSQL returns the ORDER BY clause of the sort criteria
+func orderSQL(sorts []internal.ProductSort) (order string, err error) {
+	var terms []string
+	for _, s := range sortKeys(sorts) {
+		column, ok := productFields[s.Field]
+		if !ok {
+			err = fmt.Errorf("repository: unknown sort field %q", s.Field)
+			return
+		}
+		if s.Desc {
+			column += " DESC"
+		}
+		terms = append(terms, column)
+	}
+	order = strings.Join(terms, ", ")
+	return
+}
+
+// keysetSQL returns the parameterized condition that matches the products after the cursor in the sort order
+// - (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... with < for descending keys
+func keysetSQL(sorts []internal.ProductSort, c internal.ProductCursor) (cond string, args []any, err error) {
+	keys := sortKeys(sorts)
+	values := append([]any{}, c.Values...)
+	if len(keys) > len(sorts) {
+		values = append(values, c.ID)
+	}
+	if len(values) != len(keys) {
+		err = fmt.Errorf("repository: cursor has %d values for %d sort keys", len(values), len(keys))
+		return
+	}
+
+	var terms []string
+	var eqs []string
+	var eqArgs []any
+	for i, k := range keys {
+		column, ok := productFields[k.Field]
+		if !ok {
+			err = fmt.Errorf("repository: unknown sort field %q", k.Field)
+			return
+		}
+		op := ">"
+		if k.Desc {
+			op = "<"
+		}
+		terms = append(terms, "("+strings.Join(append(eqs, column+" "+op+" ?"), " AND ")+")")
+		args = append(append(args, eqArgs...), values[i])
+		eqs = append(eqs, column+" = ?")
+		eqArgs = append(eqArgs, values[i])
+	}
+	cond = "(" + strings.Join(terms, " OR ") + ")"
+	return
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// scanner is an interface implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanProduct scans a row of productColumns into a product, followed by the extra columns of the row
+func scanProduct(s scanner, extra ...any) (p internal.Product, err error) {
+	dest := []any{&p.ID, &p.Name, &p.Quantity, &p.CodeValue, &p.IsPublished, &p.Expiration, &p.Price, &p.WarehouseID, &p.DeletedAt, &p.Version}
+	err = s.Scan(append(dest, extra...)...)
+	return
 }
 
 // GetOne returns a product by id
-func (r *ProductsMySQL) GetOne(id int) (p internal.Product, err error) {
+func (r *ProductsMySQL) GetOne(ctx context.Context, id int) (p internal.Product, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	// execute the query
-	row := r.db.QueryRow(
-		"SELECT `id`, `name`, `quantity`, `code_value`, `is_published`, `expiration`, `price` " +
-		"FROM `products` WHERE `id` = ?",
+	row := r.db.QueryRowContext(
+		ctx,
+		"SELECT "+productColumns+" FROM `products` WHERE `id` = ? AND "+notDeleted,
 		id,
 	)
 	if err = row.Err(); err != nil {
@@ -31,7 +195,7 @@ func (r *ProductsMySQL) GetOne(id int) (p internal.Product, err error) {
 	}
 
 	// scan the row into the product
-	err = row.Scan(&p.ID, &p.Name, &p.Quantity, &p.CodeValue, &p.IsPublished, &p.Expiration, &p.Price)
+	p, err = scanProduct(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			err = internal.ErrProductNotFound
@@ -42,15 +206,287 @@ func (r *ProductsMySQL) GetOne(id int) (p internal.Product, err error) {
 	return
 }
 
+// queryConds returns the parameterized conditions the products of a query must match
+func queryConds(q internal.ProductQuery) (conds []string, args []any, err error) {
+	if q.Trashed {
+		conds = append(conds, "`deleted_at` IS NOT NULL")
+	} else {
+		conds = append(conds, notDeleted)
+	}
+	if q.Filter != nil {
+		var cond string
+		cond, args, err = filterSQL(q.Filter)
+		if err != nil {
+			return
+		}
+		conds = append(conds, cond)
+	}
+	return
+}
+
+// whereSQL returns the WHERE clause joining conds, or an empty string if there are none
+func whereSQL(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+// GetAll returns a page of products and the total number of products, not counted (0) for keyset pages
+func (r *ProductsMySQL) GetAll(ctx context.Context, q internal.ProductQuery) (p []internal.Product, total int, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// build the conditions
+	// - the arguments of the source come first, as it precedes the conditions in the query
+	from, args := productSource(q.AsOf)
+	conds, condArgs, err := queryConds(q)
+	if err != nil {
+		return
+	}
+	args = append(args, condArgs...)
+	where := whereSQL(conds)
+
+	// count the products
+	// - keyset pages are not counted, so walking a large catalog page by page does not count it on every page
+	if q.After == nil {
+		err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+from+where, args...).Scan(&total)
+		if err != nil {
+			return
+		}
+	}
+
+	// build the order
+	order, err := orderSQL(q.Sort)
+	if err != nil {
+		return
+	}
+
+	// execute the query
+	// - keyset pagination seeks past the last product seen, so rows inserted or deleted before it do not shift the page
+	if q.After != nil {
+		var cond string
+		cond, condArgs, err = keysetSQL(q.Sort, *q.After)
+		if err != nil {
+			return
+		}
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+		where = whereSQL(conds)
+	}
+	query := "SELECT " + productColumns + " FROM " + from + where + " ORDER BY " + order
+	if q.After != nil {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	} else {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, q.Limit, q.Offset)
+	}
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the products
+	p = make([]internal.Product, 0, q.Limit)
+	for rows.Next() {
+		var pr internal.Product
+		pr, err = scanProduct(rows)
+		if err != nil {
+			return
+		}
+		p = append(p, pr)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// Export calls fn with every product matching the filter of q, in its sort order, as rows are read
+// - Limit, Offset and After are ignored, the export stops when ctx is done or fn returns an error
+func (r *ProductsMySQL) Export(ctx context.Context, q internal.ProductQuery, fn func(p internal.Product) error) (err error) {
+	// build the query
+	conds, args, err := queryConds(q)
+	if err != nil {
+		return
+	}
+	order, err := orderSQL(q.Sort)
+	if err != nil {
+		return
+	}
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, "SELECT "+productColumns+" FROM `products`"+whereSQL(conds)+" ORDER BY "+order, args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows one at a time
+	for rows.Next() {
+		var p internal.Product
+		p, err = scanProduct(rows)
+		if err != nil {
+			return
+		}
+		if err = fn(p); err != nil {
+			return
+		}
+	}
+	err = rows.Err()
+
+	return
+}
+
+// Search returns the products matching a full-text search ranked by relevance
+func (r *ProductsMySQL) Search(ctx context.Context, s internal.ProductSearch) (res []internal.ProductSearchResult, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	mode := "IN NATURAL LANGUAGE MODE"
+	if s.Boolean {
+		mode = "IN BOOLEAN MODE"
+	}
+	match := "MATCH(`name`) AGAINST (? " + mode + ")"
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+productColumns+", "+match+" AS `score` FROM `products` "+
+			"WHERE "+match+" AND "+notDeleted+" ORDER BY `score` DESC, `id` LIMIT ?",
+		s.Query, s.Query, s.Limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the results
+	res = make([]internal.ProductSearchResult, 0, s.Limit)
+	for rows.Next() {
+		var sr internal.ProductSearchResult
+		sr.Product, err = scanProduct(rows, &sr.Score)
+		if err != nil {
+			return
+		}
+		res = append(res, sr)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// Suggest returns the values of a field (name or code_value) starting with prefix, ordered by value
+// - the values are ordered by their bytes, a binary collation, so the order is the one of the suggestion caches
+func (r *ProductsMySQL) Suggest(ctx context.Context, field string, prefix string, limit int) (s []internal.ProductSuggestion, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// check the field
+	if field != "name" && field != "code_value" {
+		err = fmt.Errorf("repository: unknown suggest field %q", field)
+		return
+	}
+	column := productFields[field]
+
+	// execute the query
+	// - a LIKE 'x%' pattern is resolved with the index of the column, whose default collation ignores accents too,
+	// the same pattern in utf8mb4_0900_as_ci then keeps only the values matching case insensitively, as the cache does
+	pattern := escapeLike(prefix) + "%"
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT `id`, "+column+" FROM `products` WHERE "+column+" LIKE ? AND "+column+" COLLATE utf8mb4_0900_as_ci LIKE ? AND "+notDeleted+" "+
+			"ORDER BY "+column+" COLLATE utf8mb4_bin, `id` LIMIT ?",
+		pattern, pattern, limit,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the suggestions
+	s = make([]internal.ProductSuggestion, 0, limit)
+	for rows.Next() {
+		var ps internal.ProductSuggestion
+		if err = rows.Scan(&ps.ID, &ps.Value); err != nil {
+			return
+		}
+		s = append(s, ps)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// lockProducts locks and returns the products matching cond within tx
+func lockProducts(ctx context.Context, tx *sql.Tx, cond string, args ...any) (p []internal.Product, err error) {
+	// execute the query
+	rows, err := tx.QueryContext(ctx, "SELECT "+productColumns+" FROM `products` WHERE "+cond+" ORDER BY `id` FOR UPDATE", args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the products
+	for rows.Next() {
+		var pr internal.Product
+		if pr, err = scanProduct(rows); err != nil {
+			return
+		}
+		p = append(p, pr)
+	}
+	err = rows.Err()
+	return
+}
+
+// lockProduct locks and returns the product of the given id matching cond within tx
+func lockProduct(ctx context.Context, tx *sql.Tx, id int, cond string) (p internal.Product, err error) {
+	ps, err := lockProducts(ctx, tx, "`id` = ? AND "+cond, id)
+	if err != nil {
+		return
+	}
+	if len(ps) == 0 {
+		err = internal.ErrProductNotFound
+		return
+	}
+	p = ps[0]
+	return
+}
+
+// idsSQL returns the parameterized condition matching the ids of the products
+func idsSQL(p []internal.Product) (cond string, args []any) {
+	cond = "`id` IN (?" + strings.Repeat(", ?", len(p)-1) + ")"
+	for _, pr := range p {
+		args = append(args, pr.ID)
+	}
+	return
+}
+
 // Store stores a product
-func (r *ProductsMySQL) Store(p *internal.Product) (err error) {
+func (r *ProductsMySQL) Store(ctx context.Context, p *internal.Product) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
 	// execute the query
-	result, err := r.db.Exec(
-		"INSERT INTO `products` (`name`, `quantity`, `code_value`, `is_published`, `expiration`, `price`) " +
-		"VALUES (?, ?, ?, ?, ?, ?)",
-		p.Name, p.Quantity, p.CodeValue, p.IsPublished, p.Expiration, p.Price,
+	result, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO `products` (`name`, `quantity`, `code_value`, `is_published`, `expiration`, `price`, `warehouse_id`) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?)",
+		p.Name, p.Quantity, p.CodeValue, p.IsPublished, p.Expiration, p.Price, p.WarehouseID,
 	)
 	if err != nil {
+		err = translateError(err)
 		return
 	}
 
@@ -60,35 +496,649 @@ func (r *ProductsMySQL) Store(p *internal.Product) (err error) {
 		return
 	}
 	p.ID = int(id)
+	p.Version = 1
+
+	// append the initial stock to the ledger
+	if err = initialMovement(ctx, tx, p); err != nil {
+		return
+	}
+
+	// record the change
+	if err = audit(ctx, tx, internal.AuditCreate, nil, p); err != nil {
+		return
+	}
+
+	// commit the transaction
+	err = tx.Commit()
+	return
+}
+
+// StoreBatch stores products in a single transaction, errs holds the error of each product (nil if stored)
+// - atomic: the first error rolls every product back, otherwise the products that fail are skipped
+// (a failed statement does not abort an InnoDB transaction, so the rest of the batch is committed)
+func (r *ProductsMySQL) StoreBatch(ctx context.Context, p []internal.Product, atomic bool) (errs []error, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// prepare the statement
+	stmt, err := tx.PrepareContext(
+		ctx,
+		"INSERT INTO `products` (`name`, `quantity`, `code_value`, `is_published`, `expiration`, `price`, `warehouse_id`) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?)",
+	)
+	if err != nil {
+		return
+	}
+	defer stmt.Close()
+
+	// execute the statement for each product
+	errs = make([]error, len(p))
+	for i := range p {
+		var result sql.Result
+		result, errs[i] = stmt.ExecContext(ctx, p[i].Name, p[i].Quantity, p[i].CodeValue, p[i].IsPublished, p[i].Expiration, p[i].Price, p[i].WarehouseID)
+		if errs[i] == nil {
+			var id int64
+			id, errs[i] = result.LastInsertId()
+			p[i].ID = int(id)
+			p[i].Version = 1
+		}
+		errs[i] = translateError(errs[i])
+		if errs[i] != nil {
+			if atomic {
+				err = errs[i]
+				return
+			}
+			continue
+		}
+
+		// append the initial stock to the ledger and record the change
+		if err = initialMovement(ctx, tx, &p[i]); err != nil {
+			return
+		}
+		if err = audit(ctx, tx, internal.AuditCreate, nil, &p[i]); err != nil {
+			return
+		}
+	}
 
+	// commit the transaction
+	err = tx.Commit()
 	return
 }
 
-// Update updates a product
-func (r *ProductsMySQL) Update(p *internal.Product) (err error) {
+// Update updates a product if its version is still p.Version, incrementing it
+// - the quantity is left as it is, it only changes through the stock ledger
+// - a new price supersedes the scheduled price changes already due
+func (r *ProductsMySQL) Update(ctx context.Context, p *internal.Product) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the product and check its version
+	before, err := lockProduct(ctx, tx, p.ID, notDeleted)
+	if err != nil {
+		return
+	}
+	if before.Version != p.Version {
+		err = internal.ErrProductVersion
+		return
+	}
+
 	// execute the query
-	_, err = r.db.Exec(
-		"UPDATE `products` SET `name` = ?, `quantity` = ?, `code_value` = ?, `is_published` = ?, `expiration` = ?, `price` = ? " +
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE `products` SET `name` = ?, `code_value` = ?, `is_published` = ?, `expiration` = ?, `price` = ?, `warehouse_id` = ?, `version` = `version` + 1 " +
 		"WHERE `id` = ?",
-		p.Name, p.Quantity, p.CodeValue, p.IsPublished, p.Expiration, p.Price, p.ID,
+		p.Name, p.CodeValue, p.IsPublished, p.Expiration, p.Price, p.WarehouseID, p.ID,
+	)
+	if err != nil {
+		err = translateError(err)
+		return
+	}
+	after := *p
+	after.Quantity = before.Quantity
+	after.Version++
+	after.DeletedAt = nil
+	if !after.Price.Equal(before.Price) {
+		if err = supersedePrices(ctx, tx, p.ID); err != nil {
+			return
+		}
+	}
+
+	// record the change
+	if err = audit(ctx, tx, internal.AuditUpdate, &before, &after); err != nil {
+		return
+	}
+
+	// commit the transaction
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	*p = after
+
+	return
+}
+
+// AdjustQuantity adds delta to the quantity of a product in a single guarded statement and returns the product adjusted
+// - concurrent adjusts of the same product never read a stale quantity, the guard is evaluated on the row being written
+func (r *ProductsMySQL) AdjustQuantity(ctx context.Context, id int, delta int) (p internal.Product, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// execute the query
+	// - stock held by active reservations can not be adjusted out
+	result, err := tx.ExecContext(
+		ctx,
+		"UPDATE `products` SET `quantity` = `quantity` + ?, `version` = `version` + 1 " +
+		"WHERE `id` = ? AND `quantity` + ? >= (" + reservedQuery + ") AND " + notDeleted,
+		delta, id, delta, id, time.Now().UTC(),
+	)
+	if err != nil {
+		err = translateError(err)
+		return
+	}
+
+	// check the product was found with enough stock
+	n, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		// - tell a missing product from an insufficient stock
+		var exists bool
+		err = tx.QueryRowContext(
+			ctx,
+			"SELECT EXISTS(SELECT 1 FROM `products` WHERE `id` = ? AND "+notDeleted+")",
+			id,
+		).Scan(&exists)
+		if err != nil {
+			return
+		}
+		err = internal.ErrProductNotFound
+		if exists {
+			err = internal.ErrStockInsufficient
+		}
+		return
+	}
+
+	// read the product adjusted, its row stays locked by the update until the commit
+	after, err := lockProduct(ctx, tx, id, notDeleted)
+	if err != nil {
+		return
+	}
+
+	// append the adjust to the ledger
+	err = insertMovement(ctx, tx, &internal.StockMovement{
+		ProductID: id,
+		Kind:      internal.MovementAdjustment,
+		Delta:     delta,
+		Reason:    internal.ReasonAdjust,
+		Balance:   after.Quantity,
+	})
+	if err != nil {
+		return
+	}
+
+	// record the change
+	before := after
+	before.Quantity -= delta
+	before.Version--
+	if err = audit(ctx, tx, internal.AuditUpdate, &before, &after); err != nil {
+		return
+	}
+
+	// commit the transaction
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	p = after
+
+	return
+}
+
+// selectorSQL returns the parameterized condition of a product selector
+func selectorSQL(s internal.ProductSelector) (cond string, args []any, err error) {
+	var conds []string
+	if len(s.IDs) > 0 {
+		conds = append(conds, "`id` IN (?"+strings.Repeat(", ?", len(s.IDs)-1)+")")
+		for _, id := range s.IDs {
+			args = append(args, id)
+		}
+	}
+	if s.Filter != nil {
+		var c string
+		var a []any
+		if c, a, err = filterSQL(s.Filter); err != nil {
+			return
+		}
+		conds = append(conds, c)
+		args = append(args, a...)
+	}
+	if len(conds) == 0 {
+		err = errors.New("repository: empty product selector")
+		return
+	}
+	conds = append(conds, notDeleted)
+	cond = strings.Join(conds, " AND ")
+	return
+}
+
+// lockBatch locks and returns the selected products within tx, enforcing the options of a batch operation
+// - proceed reports whether the operation must be applied
+func lockBatch(ctx context.Context, tx *sql.Tx, cond string, args []any, o internal.BatchOptions) (p []internal.Product, proceed bool, err error) {
+	p, err = lockProducts(ctx, tx, cond, args...)
+	if err != nil {
+		return
+	}
+	if o.MaxAffected > 0 && len(p) > o.MaxAffected {
+		err = internal.ErrProductBatchLimit
+		return
+	}
+	proceed = len(p) > 0
+	return
+}
+
+// countBatch returns the number of selected products, enforcing the options of a batch operation
+// - used by dry runs, so the products are neither locked nor read
+func (r *ProductsMySQL) countBatch(ctx context.Context, cond string, args []any, o internal.BatchOptions) (n int, err error) {
+	// execute the query
+	row := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM `products` WHERE "+cond, args...)
+	if err = row.Scan(&n); err != nil {
+		return
+	}
+	if o.MaxAffected > 0 && n > o.MaxAffected {
+		err = internal.ErrProductBatchLimit
+	}
+	return
+}
+
+// applyPatch sets the fields of a patch on a product
+func applyPatch(p *internal.Product, patch internal.ProductPatch) {
+	if patch.Name != nil {
+		p.Name = *patch.Name
+	}
+	if patch.CodeValue != nil {
+		p.CodeValue = *patch.CodeValue
+	}
+	if patch.IsPublished != nil {
+		p.IsPublished = *patch.IsPublished
+	}
+	if patch.Expiration != nil {
+		p.Expiration = *patch.Expiration
+	}
+	if patch.Price != nil {
+		p.Price = *patch.Price
+	}
+	if patch.WarehouseID != nil {
+		p.WarehouseID = patch.WarehouseID
+	}
+}
+
+// UpdateBatch applies a patch to the selected products in a single transaction and returns the number of products affected
+func (r *ProductsMySQL) UpdateBatch(ctx context.Context, s internal.ProductSelector, patch internal.ProductPatch, o internal.BatchOptions) (n int, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// build the query
+	cond, condArgs, err := selectorSQL(s)
+	if err != nil {
+		return
+	}
+	var sets []string
+	var args []any
+	set := func(column string, value any) {
+		sets = append(sets, column+" = ?")
+		args = append(args, value)
+	}
+	if patch.Name != nil {
+		set("`name`", *patch.Name)
+	}
+	if patch.CodeValue != nil {
+		set("`code_value`", *patch.CodeValue)
+	}
+	if patch.IsPublished != nil {
+		set("`is_published`", *patch.IsPublished)
+	}
+	if patch.Expiration != nil {
+		set("`expiration`", *patch.Expiration)
+	}
+	if patch.Price != nil {
+		set("`price`", *patch.Price)
+	}
+	if patch.WarehouseID != nil {
+		set("`warehouse_id`", *patch.WarehouseID)
+	}
+	if len(sets) == 0 {
+		err = errors.New("repository: empty product patch")
+		return
+	}
+	sets = append(sets, "`version` = `version` + 1")
+
+	// count the products of a dry run
+	if o.DryRun {
+		n, err = r.countBatch(ctx, cond, condArgs, o)
+		return
+	}
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the products
+	p, proceed, err := lockBatch(ctx, tx, cond, condArgs, o)
+	n = len(p)
+	if err != nil || !proceed {
+		return
+	}
+
+	// execute the query
+	ids, idArgs := idsSQL(p)
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE `products` SET "+strings.Join(sets, ", ")+" WHERE "+ids,
+		append(args, idArgs...)...,
+	)
+	if err != nil {
+		err = translateError(err)
+		return
+	}
+
+	if patch.Price != nil {
+		ids := make([]int, len(p))
+		for i := range p {
+			ids[i] = p[i].ID
+		}
+		if err = supersedePrices(ctx, tx, ids...); err != nil {
+			return
+		}
+	}
+
+	// record the changes
+	for i := range p {
+		after := p[i]
+		applyPatch(&after, patch)
+		after.Version++
+		if err = audit(ctx, tx, internal.AuditUpdate, &p[i], &after); err != nil {
+			return
+		}
+	}
+
+	// commit the transaction
+	err = tx.Commit()
+	return
+}
+
+// Delete moves a product to the trash
+func (r *ProductsMySQL) Delete(ctx context.Context, id int) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the product
+	before, err := lockProduct(ctx, tx, id, notDeleted)
+	if err != nil {
+		return
+	}
+
+	// execute the query
+	now := time.Now().UTC().Truncate(time.Second)
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE `products` SET `deleted_at` = ?, `version` = `version` + 1 WHERE `id` = ?",
+		now, id,
 	)
 	if err != nil {
+		err = translateError(err)
 		return
 	}
 
+	// record the change
+	after := before
+	after.DeletedAt = &now
+	after.Version++
+	if err = audit(ctx, tx, internal.AuditDelete, &before, &after); err != nil {
+		return
+	}
+
+	// commit the transaction
+	err = tx.Commit()
 	return
 }
 
-// Delete deletes a product by id
-func (r *ProductsMySQL) Delete(id int) (err error) {
+// DeleteBatch moves the selected products to the trash in a single transaction and returns the number of products affected
+func (r *ProductsMySQL) DeleteBatch(ctx context.Context, s internal.ProductSelector, o internal.BatchOptions) (n int, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// build the query
+	cond, args, err := selectorSQL(s)
+	if err != nil {
+		return
+	}
+
+	// count the products of a dry run
+	if o.DryRun {
+		n, err = r.countBatch(ctx, cond, args, o)
+		return
+	}
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the products
+	p, proceed, err := lockBatch(ctx, tx, cond, args, o)
+	n = len(p)
+	if err != nil || !proceed {
+		return
+	}
+
 	// execute the query
-	_, err = r.db.Exec(
+	now := time.Now().UTC().Truncate(time.Second)
+	ids, idArgs := idsSQL(p)
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE `products` SET `deleted_at` = ?, `version` = `version` + 1 WHERE "+ids,
+		append([]any{now}, idArgs...)...,
+	)
+	if err != nil {
+		err = translateError(err)
+		return
+	}
+
+	// record the changes
+	for i := range p {
+		after := p[i]
+		after.DeletedAt = &now
+		after.Version++
+		if err = audit(ctx, tx, internal.AuditDelete, &p[i], &after); err != nil {
+			return
+		}
+	}
+
+	// commit the transaction
+	err = tx.Commit()
+	return
+}
+
+// Restore moves a product out of the trash
+func (r *ProductsMySQL) Restore(ctx context.Context, id int) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the product in the trash
+	before, err := lockProduct(ctx, tx, id, "`deleted_at` IS NOT NULL")
+	if err != nil {
+		return
+	}
+
+	// execute the query
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE `products` SET `deleted_at` = NULL, `version` = `version` + 1 WHERE `id` = ?",
+		id,
+	)
+	if err != nil {
+		err = translateError(err)
+		return
+	}
+
+	// record the change
+	after := before
+	after.DeletedAt = nil
+	after.Version++
+	if err = audit(ctx, tx, internal.AuditRestore, &before, &after); err != nil {
+		return
+	}
+
+	// commit the transaction
+	err = tx.Commit()
+	return
+}
+
+// Purge deletes a product permanently, whether it is in the trash or not
+// - its history is kept
+func (r *ProductsMySQL) Purge(ctx context.Context, id int) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the product
+	before, err := lockProduct(ctx, tx, id, "TRUE")
+	if err != nil {
+		return
+	}
+
+	// execute the query
+	_, err = tx.ExecContext(
+		ctx,
 		"DELETE FROM `products` WHERE `id` = ?",
 		id,
 	)
 	if err != nil {
+		err = translateError(err)
+		return
+	}
+
+	// record the change
+	if err = audit(ctx, tx, internal.AuditPurge, &before, nil); err != nil {
+		return
+	}
+
+	// commit the transaction
+	err = tx.Commit()
+	return
+}
+
+// ExpireBatchSize is the maximum number of products unpublished by a call of UnpublishExpired
+// - a call that unpublishes fewer products left none expired behind
+const ExpireBatchSize = 500
+
+// UnpublishExpired unpublishes the published products whose expiration is before the date of at, in a single transaction
+// - at most ExpireBatchSize products are unpublished per call, the rest are left to the next one
+func (r *ProductsMySQL) UnpublishExpired(ctx context.Context, at time.Time) (n int, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the products expired
+	// - the batch is picked in a derived table, as MySQL does not support LIMIT in an IN subquery
+	expired := "`is_published` = 1 AND `expiration` < ? AND " + notDeleted
+	date := at.Format(time.DateOnly)
+	p, err := lockProducts(
+		ctx, tx,
+		expired+" AND `id` IN (SELECT `id` FROM (SELECT `id` FROM `products` WHERE "+expired+" ORDER BY `id` LIMIT ?) AS `batch`)",
+		date, date, ExpireBatchSize,
+	)
+	if err != nil || len(p) == 0 {
+		return
+	}
+
+	// execute the query
+	ids, args := idsSQL(p)
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE `products` SET `is_published` = 0, `version` = `version` + 1 WHERE "+ids,
+		args...,
+	)
+	if err != nil {
+		err = translateError(err)
 		return
 	}
 
+	// record the changes
+	for i := range p {
+		after := p[i]
+		after.IsPublished = false
+		after.Version++
+		if err = audit(ctx, tx, internal.AuditExpire, &p[i], &after); err != nil {
+			return
+		}
+	}
+
+	// commit the transaction
+	if err = tx.Commit(); err != nil {
+		return
+	}
+	n = len(p)
 	return
-}
\ No newline at end of file
+}
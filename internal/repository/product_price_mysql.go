@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"app/internal"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// NewProductPricesMySQL returns a new instance of ProductPricesMySQL
+// - timeout bounds every query, 0 means no timeout other than the one of the context
+func NewProductPricesMySQL(db *sql.DB, timeout time.Duration) *ProductPricesMySQL {
+	return &ProductPricesMySQL{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+// ProductPricesMySQL is a struct that represents a repository of the prices of the products in other currencies
+type ProductPricesMySQL struct {
+	// db is the database connection
+	db *sql.DB
+	// timeout is the maximum duration of a query
+	timeout time.Duration
+}
+
+// GetAll returns the prices of a product in other currencies, ordered by currency
+func (r *ProductPricesMySQL) GetAll(ctx context.Context, productID int) (p []internal.ProductPrice, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT `product_id`, `currency`, `price`, `actor`, `updated_at` FROM `product_prices` " +
+		"WHERE `product_id` = ? ORDER BY `currency`",
+		productID,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the prices
+	p = make([]internal.ProductPrice, 0)
+	for rows.Next() {
+		var pp internal.ProductPrice
+		var currency, price string
+		if err = rows.Scan(&pp.ProductID, &currency, &price, &pp.Actor, &pp.UpdatedAt); err != nil {
+			return
+		}
+		if pp.Price, err = internal.ParseMoney(price, currency); err != nil {
+			return
+		}
+		p = append(p, pp)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// In returns the price in a currency of each of the products that have one
+func (r *ProductPricesMySQL) In(ctx context.Context, productIDs []int, currency string) (prices map[int]internal.Money, err error) {
+	prices = make(map[int]internal.Money)
+	if len(productIDs) == 0 {
+		return
+	}
+
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	args := []any{currency}
+	for _, id := range productIDs {
+		args = append(args, id)
+	}
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT `product_id`, `price` FROM `product_prices` WHERE `currency` = ? " +
+		"AND `product_id` IN (?" + strings.Repeat(", ?", len(productIDs)-1) + ")",
+		args...,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the prices
+	for rows.Next() {
+		var id int
+		price := internal.NewMoney(0, 0, currency)
+		if err = rows.Scan(&id, &price); err != nil {
+			return
+		}
+		prices[id] = price
+	}
+	err = rows.Err()
+
+	return
+}
+
+// Set sets the price of a product in the currency of p.Price
+func (r *ProductPricesMySQL) Set(ctx context.Context, p *internal.ProductPrice) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the product, so it is not purged meanwhile
+	if _, err = lockProduct(ctx, tx, p.ProductID, notDeleted); err != nil {
+		return
+	}
+
+	// execute the query
+	p.Actor = actorOf(ctx)
+	p.UpdatedAt = time.Now().UTC()
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO `product_prices` (`product_id`, `currency`, `price`, `actor`, `updated_at`) VALUES (?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE `price` = VALUES(`price`), `actor` = VALUES(`actor`), `updated_at` = VALUES(`updated_at`)",
+		p.ProductID, p.Price.Currency(), p.Price, p.Actor, p.UpdatedAt,
+	)
+	if err != nil {
+		err = translateError(err)
+		return
+	}
+
+	// commit the transaction
+	err = tx.Commit()
+	return
+}
+
+// Delete deletes the price of a product in a currency
+func (r *ProductPricesMySQL) Delete(ctx context.Context, productID int, currency string) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	result, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM `product_prices` WHERE `product_id` = ? AND `currency` = ?",
+		productID, currency,
+	)
+	if err != nil {
+		return
+	}
+
+	// check the price was found
+	n, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		err = internal.ErrProductPriceNotFound
+		return
+	}
+
+	return
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"app/internal"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchProducts is a token-matching fallback of a full-text search for repositories without a full-text index
+// - the score of a product is the number of query terms found in its name
+// - in boolean mode, terms prefixed with + are required, terms prefixed with - are excluded
+// and terms suffixed with * match any word starting with them
+func SearchProducts(ps []internal.Product, s internal.ProductSearch) (res []internal.ProductSearchResult) {
+	terms := strings.Fields(strings.ToLower(s.Query))
+
+	res = make([]internal.ProductSearchResult, 0)
+	for _, p := range ps {
+		words := strings.FieldsFunc(strings.ToLower(p.Name), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+
+		score := 0.0
+		matched := true
+		for _, term := range terms {
+			required, excluded, prefix := false, false, false
+			if s.Boolean {
+				switch {
+				case strings.HasPrefix(term, "+"):
+					required, term = true, term[1:]
+				case strings.HasPrefix(term, "-"):
+					excluded, term = true, term[1:]
+				}
+				if strings.HasSuffix(term, "*") {
+					prefix, term = true, strings.TrimSuffix(term, "*")
+				}
+				term = strings.Trim(term, `"()`)
+			}
+			if term == "" {
+				continue
+			}
+
+			found := false
+			for _, w := range words {
+				if w == term || (prefix && strings.HasPrefix(w, term)) {
+					found = true
+					break
+				}
+			}
+			switch {
+			case found && excluded, !found && required:
+				matched = false
+			case found:
+				score++
+			}
+		}
+		if !matched || score == 0 {
+			continue
+		}
+		res = append(res, internal.ProductSearchResult{Product: p, Score: score})
+	}
+
+	// rank by relevance
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		return res[i].Product.ID < res[j].Product.ID
+	})
+	if s.Limit > 0 && len(res) > s.Limit {
+		res = res[:s.Limit]
+	}
+	return
+}
@@ -0,0 +1,105 @@
+package repository_test
+
+import (
+	"app/internal"
+	"app/internal/repository"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for SearchProducts function
+func TestSearchProducts(t *testing.T) {
+	ps := []internal.Product{
+		{ID: 1, Name: "Whole milk"},
+		{ID: 2, Name: "Milk chocolate bar"},
+		{ID: 3, Name: "Dark chocolate"},
+		{ID: 4, Name: "Milkshake, strawberry"},
+		{ID: 5, Name: "White bread"},
+	}
+	ids := func(res []internal.ProductSearchResult) (ids []int) {
+		for _, r := range res {
+			ids = append(ids, r.Product.ID)
+		}
+		return
+	}
+
+	t.Run("natural - any term matches, case insensitive", func(t *testing.T) {
+		// arrange
+		s := internal.ProductSearch{Query: "MILK bread"}
+
+		// act
+		res := repository.SearchProducts(ps, s)
+
+		// assert
+		require.Equal(t, []int{1, 2, 5}, ids(res))
+	})
+
+	t.Run("natural - ranked by the number of terms found, then by id", func(t *testing.T) {
+		// arrange
+		s := internal.ProductSearch{Query: "chocolate milk"}
+
+		// act
+		res := repository.SearchProducts(ps, s)
+
+		// assert
+		require.Equal(t, []int{2, 1, 3}, ids(res))
+		require.Equal(t, 2.0, res[0].Score)
+		require.Equal(t, 1.0, res[1].Score)
+	})
+
+	t.Run("natural - boolean operators are plain terms", func(t *testing.T) {
+		// arrange
+		s := internal.ProductSearch{Query: "+milk -chocolate"}
+
+		// act
+		res := repository.SearchProducts(ps, s)
+
+		// assert
+		require.Empty(t, res)
+	})
+
+	t.Run("boolean - required and excluded terms", func(t *testing.T) {
+		// arrange
+		s := internal.ProductSearch{Query: "+milk -chocolate", Boolean: true}
+
+		// act
+		res := repository.SearchProducts(ps, s)
+
+		// assert
+		require.Equal(t, []int{1}, ids(res))
+	})
+
+	t.Run("boolean - prefix terms", func(t *testing.T) {
+		// arrange
+		s := internal.ProductSearch{Query: "milk*", Boolean: true}
+
+		// act
+		res := repository.SearchProducts(ps, s)
+
+		// assert
+		require.Equal(t, []int{1, 2, 4}, ids(res))
+	})
+
+	t.Run("boolean - only excluded terms match nothing", func(t *testing.T) {
+		// arrange
+		s := internal.ProductSearch{Query: "-chocolate", Boolean: true}
+
+		// act
+		res := repository.SearchProducts(ps, s)
+
+		// assert
+		require.Empty(t, res)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		// arrange
+		s := internal.ProductSearch{Query: "milk chocolate", Limit: 2}
+
+		// act
+		res := repository.SearchProducts(ps, s)
+
+		// assert
+		require.Equal(t, []int{2, 1}, ids(res))
+	})
+}
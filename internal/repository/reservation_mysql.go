@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"app/internal"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// NewReservationsMySQL returns a new instance of ReservationsMySQL
+// - timeout bounds every query, 0 means no timeout other than the one of the context
+func NewReservationsMySQL(db *sql.DB, timeout time.Duration) *ReservationsMySQL {
+	return &ReservationsMySQL{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+// ReservationsMySQL is a struct that represents a reservation repository
+type ReservationsMySQL struct {
+	// db is the database connection
+	db *sql.DB
+	// timeout is the maximum duration of a query
+	timeout time.Duration
+}
+
+// reservationColumns is the list of columns selected for a reservation
+const reservationColumns = "`id`, `product_id`, `quantity`, `status`, `reference`, `actor`, `created_at`, `expires_at`, `resolved_at`"
+
+// reservedQuery is the query of the quantity held by the active reservations of a product, bound by its id and the current time
+// - reservations past their expiration hold nothing, even before they are swept
+const reservedQuery = "SELECT COALESCE(SUM(`quantity`), 0) FROM `reservations` " +
+	"WHERE `product_id` = ? AND `status` = 'active' AND `expires_at` > ?"
+
+// reserved returns the quantity held by the active reservations of a product within tx
+func reserved(ctx context.Context, tx *sql.Tx, productID int) (n int, err error) {
+	err = tx.QueryRowContext(ctx, reservedQuery, productID, time.Now().UTC()).Scan(&n)
+	return
+}
+
+// scanReservation scans a row of reservationColumns into a reservation
+func scanReservation(s scanner) (rs internal.Reservation, err error) {
+	err = s.Scan(&rs.ID, &rs.ProductID, &rs.Quantity, &rs.Status, &rs.Reference, &rs.Actor, &rs.CreatedAt, &rs.ExpiresAt, &rs.ResolvedAt)
+	return
+}
+
+// Reserve holds stock of a product until r.ExpiresAt
+func (r *ReservationsMySQL) Reserve(ctx context.Context, rs *internal.Reservation) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the product and check its available stock
+	// - the lock serializes the reservations of the product, so the stock is never held twice
+	p, err := lockProduct(ctx, tx, rs.ProductID, notDeleted)
+	if err != nil {
+		return
+	}
+	n, err := reserved(ctx, tx, rs.ProductID)
+	if err != nil {
+		return
+	}
+	if p.Quantity-n < rs.Quantity {
+		err = internal.ErrStockInsufficient
+		return
+	}
+
+	// execute the query
+	rs.Status = internal.ReservationActive
+	rs.Actor = actorOf(ctx)
+	rs.CreatedAt = time.Now().UTC()
+	rs.ResolvedAt = nil
+	result, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO `reservations` (`product_id`, `quantity`, `status`, `reference`, `actor`, `created_at`, `expires_at`) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?)",
+		rs.ProductID, rs.Quantity, rs.Status, rs.Reference, rs.Actor, rs.CreatedAt, rs.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		err = translateError(err)
+		return
+	}
+
+	// get the last inserted id
+	id, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+	rs.ID = int(id)
+
+	// commit the transaction
+	err = tx.Commit()
+	return
+}
+
+// Stock returns the quantity of a product and the quantity held by its active reservations
+func (r *ReservationsMySQL) Stock(ctx context.Context, productID int) (quantity, reserved int, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	err = r.db.QueryRowContext(
+		ctx,
+		"SELECT `quantity`, ("+reservedQuery+") FROM `products` WHERE `id` = ? AND "+notDeleted,
+		productID, time.Now().UTC(), productID,
+	).Scan(&quantity, &reserved)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = internal.ErrProductNotFound
+		}
+		return
+	}
+
+	return
+}
+
+// GetActive returns the active reservations of a product, ordered by expiration
+func (r *ReservationsMySQL) GetActive(ctx context.Context, productID int) (rs []internal.Reservation, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT "+reservationColumns+" FROM `reservations` " +
+		"WHERE `product_id` = ? AND `status` = 'active' AND `expires_at` > ? ORDER BY `expires_at`, `id`",
+		productID, time.Now().UTC(),
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the reservations
+	rs = make([]internal.Reservation, 0)
+	for rows.Next() {
+		var res internal.Reservation
+		if res, err = scanReservation(rows); err != nil {
+			return
+		}
+		rs = append(rs, res)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// lockReservation locks and returns an active reservation of a product within tx
+// - a reservation past its expiration is not active, even before it is swept
+func lockReservation(ctx context.Context, tx *sql.Tx, productID, id int) (rs internal.Reservation, err error) {
+	row := tx.QueryRowContext(
+		ctx,
+		"SELECT "+reservationColumns+" FROM `reservations` WHERE `id` = ? AND `product_id` = ? FOR UPDATE",
+		id, productID,
+	)
+	rs, err = scanReservation(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = internal.ErrReservationNotFound
+		}
+		return
+	}
+	if rs.Status != internal.ReservationActive || !rs.ExpiresAt.After(time.Now()) {
+		err = internal.ErrReservationNotActive
+		return
+	}
+	return
+}
+
+// resolve sets the final status of a reservation within tx
+func resolve(ctx context.Context, tx *sql.Tx, rs *internal.Reservation, status string) (err error) {
+	now := time.Now().UTC()
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE `reservations` SET `status` = ?, `resolved_at` = ? WHERE `id` = ?",
+		status, now, rs.ID,
+	)
+	if err != nil {
+		return
+	}
+	rs.Status = status
+	rs.ResolvedAt = &now
+	return
+}
+
+// Confirm takes the stock of an active reservation out of the product, appending it to the stock ledger
+func (r *ReservationsMySQL) Confirm(ctx context.Context, productID, id int) (rs internal.Reservation, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the product, then the reservation, in the same order as Reserve
+	before, err := lockProduct(ctx, tx, productID, notDeleted)
+	if err != nil {
+		return
+	}
+	rs, err = lockReservation(ctx, tx, productID, id)
+	if err != nil {
+		return
+	}
+	after := before
+	after.Quantity -= rs.Quantity
+	after.Version++
+	if after.Quantity < 0 {
+		err = internal.ErrStockInsufficient
+		return
+	}
+
+	// execute the query
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE `products` SET `quantity` = ?, `version` = `version` + 1 WHERE `id` = ?",
+		after.Quantity, after.ID,
+	)
+	if err != nil {
+		err = translateError(err)
+		return
+	}
+
+	// append the movement and record the change
+	err = insertMovement(ctx, tx, &internal.StockMovement{
+		ProductID: productID,
+		Kind:      internal.MovementOutbound,
+		Delta:     -rs.Quantity,
+		Reason:    internal.ReasonReservation,
+		Reference: fmt.Sprintf("reservation:%d", rs.ID),
+		Balance:   after.Quantity,
+	})
+	if err != nil {
+		return
+	}
+	if err = audit(ctx, tx, internal.AuditUpdate, &before, &after); err != nil {
+		return
+	}
+
+	// resolve the reservation
+	if err = resolve(ctx, tx, &rs, internal.ReservationConfirmed); err != nil {
+		return
+	}
+
+	// commit the transaction
+	err = tx.Commit()
+	return
+}
+
+// Cancel releases the stock of an active reservation
+func (r *ReservationsMySQL) Cancel(ctx context.Context, productID, id int) (rs internal.Reservation, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the reservation
+	rs, err = lockReservation(ctx, tx, productID, id)
+	if err != nil {
+		return
+	}
+
+	// resolve the reservation
+	if err = resolve(ctx, tx, &rs, internal.ReservationCancelled); err != nil {
+		return
+	}
+
+	// commit the transaction
+	err = tx.Commit()
+	return
+}
+
+// Expire releases the stock of the active reservations expired at now and returns their number
+func (r *ReservationsMySQL) Expire(ctx context.Context, now time.Time) (n int, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	result, err := r.db.ExecContext(
+		ctx,
+		"UPDATE `reservations` SET `status` = 'expired', `resolved_at` = `expires_at` WHERE `status` = 'active' AND `expires_at` <= ?",
+		now.UTC(),
+	)
+	if err != nil {
+		return
+	}
+
+	// get the number of reservations expired
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	n = int(rows)
+
+	return
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"app/internal"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// NewStockMovementsMySQL returns a new instance of StockMovementsMySQL
+// - timeout bounds every query, 0 means no timeout other than the one of the context
+func NewStockMovementsMySQL(db *sql.DB, timeout time.Duration) *StockMovementsMySQL {
+	return &StockMovementsMySQL{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+// StockMovementsMySQL is a struct that represents a stock ledger repository
+type StockMovementsMySQL struct {
+	// db is the database connection
+	db *sql.DB
+	// timeout is the maximum duration of a query
+	timeout time.Duration
+}
+
+// insertMovement appends a movement to the ledger within tx, made by the caller bound to ctx
+// - m.Balance must already hold the stock after the movement
+func insertMovement(ctx context.Context, tx *sql.Tx, m *internal.StockMovement) (err error) {
+	m.Actor = actorOf(ctx)
+	m.CreatedAt = time.Now().UTC()
+
+	// execute the query
+	result, err := tx.ExecContext(
+		ctx,
+		"INSERT INTO `stock_movements` (`product_id`, `kind`, `delta`, `reason`, `reference`, `balance`, `actor`, `created_at`) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		m.ProductID, m.Kind, m.Delta, m.Reason, m.Reference, m.Balance, m.Actor, m.CreatedAt,
+	)
+	if err != nil {
+		return
+	}
+
+	// get the last inserted id
+	id, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+	m.ID = int(id)
+
+	return
+}
+
+// initialMovement appends the movement of the quantity a product is created with to the ledger within tx
+// - a product created with no stock has no initial movement
+func initialMovement(ctx context.Context, tx *sql.Tx, p *internal.Product) (err error) {
+	if p.Quantity == 0 {
+		return
+	}
+	return insertMovement(ctx, tx, &internal.StockMovement{
+		ProductID: p.ID,
+		Kind:      internal.MovementInbound,
+		Delta:     p.Quantity,
+		Reason:    internal.ReasonInitial,
+		Balance:   p.Quantity,
+	})
+}
+
+// Record appends a movement to the ledger of a product and applies it to its quantity in a single transaction
+func (r *StockMovementsMySQL) Record(ctx context.Context, m *internal.StockMovement) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// begin the transaction
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	defer tx.Rollback()
+
+	// lock the product and check its stock
+	// - stock held by active reservations can not be moved out
+	before, err := lockProduct(ctx, tx, m.ProductID, notDeleted)
+	if err != nil {
+		return
+	}
+	after := before
+	after.Quantity += m.Delta
+	after.Version++
+	if m.Delta < 0 {
+		var n int
+		if n, err = reserved(ctx, tx, m.ProductID); err != nil {
+			return
+		}
+		if after.Quantity < n {
+			err = internal.ErrStockInsufficient
+			return
+		}
+	}
+	if after.Quantity < 0 {
+		err = internal.ErrStockInsufficient
+		return
+	}
+
+	// execute the query
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE `products` SET `quantity` = ?, `version` = `version` + 1 WHERE `id` = ?",
+		after.Quantity, after.ID,
+	)
+	if err != nil {
+		err = translateError(err)
+		return
+	}
+
+	// append the movement
+	m.Balance = after.Quantity
+	if err = insertMovement(ctx, tx, m); err != nil {
+		return
+	}
+
+	// record the change
+	if err = audit(ctx, tx, internal.AuditUpdate, &before, &after); err != nil {
+		return
+	}
+
+	// commit the transaction
+	err = tx.Commit()
+	return
+}
+
+// GetAll returns a page of the movements of a product, newest first, and the total number of movements
+func (r *StockMovementsMySQL) GetAll(ctx context.Context, productID int, limit, offset int) (m []internal.StockMovement, total int, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// count the movements
+	err = r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM `stock_movements` WHERE `product_id` = ?", productID).Scan(&total)
+	if err != nil {
+		return
+	}
+
+	// execute the query
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT `id`, `product_id`, `kind`, `delta`, `reason`, `reference`, `balance`, `actor`, `created_at` " +
+		"FROM `stock_movements` WHERE `product_id` = ? ORDER BY `id` DESC LIMIT ? OFFSET ?",
+		productID, limit, offset,
+	)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the movements
+	m = make([]internal.StockMovement, 0, limit)
+	for rows.Next() {
+		var sm internal.StockMovement
+		err = rows.Scan(&sm.ID, &sm.ProductID, &sm.Kind, &sm.Delta, &sm.Reason, &sm.Reference, &sm.Balance, &sm.Actor, &sm.CreatedAt)
+		if err != nil {
+			return
+		}
+		m = append(m, sm)
+	}
+	err = rows.Err()
+
+	return
+}
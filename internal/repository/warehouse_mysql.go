@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"app/internal"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// NewWarehousesMySQL returns a new instance of WarehousesMySQL
+// - timeout bounds every query, 0 means no timeout other than the one of the context
+func NewWarehousesMySQL(db *sql.DB, timeout time.Duration) *WarehousesMySQL {
+	return &WarehousesMySQL{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+// WarehousesMySQL is a struct that represents a warehouse repository
+type WarehousesMySQL struct {
+	// db is the database connection
+	db *sql.DB
+	// timeout is the maximum duration of a query
+	timeout time.Duration
+}
+
+// translateWarehouseError translates the errors of the mysql driver into the errors of internal.RepositoryWarehouses
+// - the only foreign key of a warehouse is the one of the products stored in it
+func translateWarehouseError(err error) error {
+	return translateErrorTo(err, internal.ErrWarehouseNotUnique, internal.ErrWarehouseInUse)
+}
+
+// GetOne returns a warehouse by id
+func (r *WarehousesMySQL) GetOne(ctx context.Context, id int) (w internal.Warehouse, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	row := r.db.QueryRowContext(
+		ctx,
+		"SELECT `id`, `code`, `name`, `address` FROM `warehouses` WHERE `id` = ?",
+		id,
+	)
+	if err = row.Err(); err != nil {
+		return
+	}
+
+	// scan the row into the warehouse
+	err = row.Scan(&w.ID, &w.Code, &w.Name, &w.Address)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			err = internal.ErrWarehouseNotFound
+		}
+		return
+	}
+
+	return
+}
+
+// GetAll returns every warehouse, ordered by id
+func (r *WarehousesMySQL) GetAll(ctx context.Context) (w []internal.Warehouse, err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	rows, err := r.db.QueryContext(ctx, "SELECT `id`, `code`, `name`, `address` FROM `warehouses` ORDER BY `id`")
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	// scan the rows into the warehouses
+	w = make([]internal.Warehouse, 0)
+	for rows.Next() {
+		var wh internal.Warehouse
+		if err = rows.Scan(&wh.ID, &wh.Code, &wh.Name, &wh.Address); err != nil {
+			return
+		}
+		w = append(w, wh)
+	}
+	err = rows.Err()
+
+	return
+}
+
+// Store stores a warehouse
+func (r *WarehousesMySQL) Store(ctx context.Context, w *internal.Warehouse) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	result, err := r.db.ExecContext(
+		ctx,
+		"INSERT INTO `warehouses` (`code`, `name`, `address`) VALUES (?, ?, ?)",
+		w.Code, w.Name, w.Address,
+	)
+	if err != nil {
+		err = translateWarehouseError(err)
+		return
+	}
+
+	// get the last inserted id
+	id, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+	w.ID = int(id)
+
+	return
+}
+
+// Update updates a warehouse
+func (r *WarehousesMySQL) Update(ctx context.Context, w *internal.Warehouse) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	result, err := r.db.ExecContext(
+		ctx,
+		"UPDATE `warehouses` SET `code` = ?, `name` = ?, `address` = ? WHERE `id` = ?",
+		w.Code, w.Name, w.Address, w.ID,
+	)
+	if err != nil {
+		err = translateWarehouseError(err)
+		return
+	}
+
+	// check the warehouse was found
+	n, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		err = internal.ErrWarehouseNotFound
+		return
+	}
+
+	return
+}
+
+// Delete deletes a warehouse
+func (r *WarehousesMySQL) Delete(ctx context.Context, id int) (err error) {
+	// bound the context with the query timeout
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// execute the query
+	result, err := r.db.ExecContext(
+		ctx,
+		"DELETE FROM `warehouses` WHERE `id` = ?",
+		id,
+	)
+	if err != nil {
+		err = translateWarehouseError(err)
+		return
+	}
+
+	// check the warehouse was found
+	n, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	if n == 0 {
+		err = internal.ErrWarehouseNotFound
+		return
+	}
+
+	return
+}
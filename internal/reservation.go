@@ -0,0 +1,36 @@
+package internal
+
+import "time"
+
+const (
+	// ReservationActive is the status of a reservation holding stock
+	ReservationActive = "active"
+	// ReservationConfirmed is the status of a reservation whose stock was taken out of the product
+	ReservationConfirmed = "confirmed"
+	// ReservationCancelled is the status of a reservation released by its caller
+	ReservationCancelled = "cancelled"
+	// ReservationExpired is the status of a reservation released because its time ran out
+	ReservationExpired = "expired"
+)
+
+// Reservation is an struct that represents a hold of stock of a product for a limited time
+type Reservation struct {
+	// ID is the unique identifier of the reservation
+	ID int
+	// ProductID is the id of the product whose stock is held
+	ProductID int
+	// Quantity is the quantity of stock held
+	Quantity int
+	// Status is the status of the reservation, one of the Reservation constants
+	Status string
+	// Reference is an optional external reference of the reservation, such as a cart id
+	Reference string
+	// Actor is the name of who made the reservation
+	Actor string
+	// CreatedAt is the time the reservation was made
+	CreatedAt time.Time
+	// ExpiresAt is the time the reservation stops holding stock unless it is confirmed before
+	ExpiresAt time.Time
+	// ResolvedAt is the time the reservation was confirmed, cancelled or expired, nil while it is active
+	ResolvedAt *time.Time
+}
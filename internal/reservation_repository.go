@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrReservationNotFound is an error that will be returned when a reservation is not found
+	ErrReservationNotFound = errors.New("repository: reservation not found")
+	// ErrReservationNotActive is an error that will be returned when a reservation was already confirmed, cancelled or expired
+	ErrReservationNotActive = errors.New("repository: reservation not active")
+)
+
+// RepositoryReservations is an interface that represents a reservation repository
+// - the available stock of a product is its quantity minus the quantity of its active reservations
+// - every method stops when ctx is done, returning context.DeadlineExceeded when its deadline expires
+type RepositoryReservations interface {
+	// Reserve holds stock of a product until r.ExpiresAt
+	// - it fails with ErrStockInsufficient if the available stock is lower than r.Quantity
+	Reserve(ctx context.Context, r *Reservation) (err error)
+	// Stock returns the quantity of a product and the quantity held by its active reservations
+	Stock(ctx context.Context, productID int) (quantity, reserved int, err error)
+	// GetActive returns the active reservations of a product, ordered by expiration
+	GetActive(ctx context.Context, productID int) (r []Reservation, err error)
+	// Confirm takes the stock of an active reservation out of the product, appending it to the stock ledger
+	Confirm(ctx context.Context, productID, id int) (r Reservation, err error)
+	// Cancel releases the stock of an active reservation
+	Cancel(ctx context.Context, productID, id int) (r Reservation, err error)
+	// Expire releases the stock of the active reservations expired at now and returns their number
+	Expire(ctx context.Context, now time.Time) (n int, err error)
+}
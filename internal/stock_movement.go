@@ -0,0 +1,43 @@
+package internal
+
+import "time"
+
+const (
+	// MovementInbound is the kind of a movement of stock into a product
+	MovementInbound = "inbound"
+	// MovementOutbound is the kind of a movement of stock out of a product
+	MovementOutbound = "outbound"
+	// MovementAdjustment is the kind of a movement that corrects the stock of a product, in either direction
+	MovementAdjustment = "adjustment"
+)
+
+const (
+	// ReasonInitial is the reason of the inbound movement of the quantity a product is created with
+	ReasonInitial = "initial"
+	// ReasonAdjust is the reason of the adjustment movement of an atomic quantity adjust
+	ReasonAdjust = "adjust"
+	// ReasonReservation is the reason of the outbound movement of a reservation confirmed
+	ReasonReservation = "reservation"
+)
+
+// StockMovement is an struct that represents an entry of the stock ledger of a product
+type StockMovement struct {
+	// ID is the unique identifier of the movement
+	ID int
+	// ProductID is the id of the product whose stock moved
+	ProductID int
+	// Kind is the kind of movement, one of the Movement constants
+	Kind string
+	// Delta is the change of the stock, positive for inbound and negative for outbound movements
+	Delta int
+	// Reason is the code of the reason of the movement
+	Reason string
+	// Reference is an optional external reference of the movement, such as an order number
+	Reference string
+	// Balance is the stock of the product after the movement
+	Balance int
+	// Actor is the name of who recorded the movement
+	Actor string
+	// CreatedAt is the time the movement was recorded
+	CreatedAt time.Time
+}
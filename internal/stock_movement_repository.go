@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrStockInsufficient is an error that will be returned when a movement would take the stock of a product below
+	// the quantity held by its active reservations, or below zero
+	ErrStockInsufficient = errors.New("repository: insufficient stock")
+)
+
+// RepositoryStockMovements is an interface that represents a stock ledger repository
+// - the ledger is append-only, the quantity of a product is the balance of its last movement
+// - every method stops when ctx is done, returning context.DeadlineExceeded when its deadline expires
+type RepositoryStockMovements interface {
+	// Record appends a movement to the ledger of a product and applies it to its quantity in a single transaction
+	// - it fails with ErrStockInsufficient if the stock would drop below the quantity reserved, ErrProductNotFound for products in the trash
+	Record(ctx context.Context, m *StockMovement) (err error)
+	// GetAll returns a page of the movements of a product, newest first, and the total number of movements
+	GetAll(ctx context.Context, productID int, limit, offset int) (m []StockMovement, total int, err error)
+}
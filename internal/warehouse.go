@@ -0,0 +1,13 @@
+package internal
+
+// Warehouse is an struct that represents a warehouse, a site where products are stored
+type Warehouse struct {
+	// ID is the unique identifier of the warehouse
+	ID int
+	// Code is the unique code of the warehouse
+	Code string
+	// Name is the name of the warehouse
+	Name string
+	// Address is the address of the warehouse
+	Address string
+}
@@ -0,0 +1,30 @@
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrWarehouseNotFound is an error that will be returned when a warehouse is not found
+	ErrWarehouseNotFound = errors.New("repository: warehouse not found")
+	// ErrWarehouseNotUnique is an error that will be returned when a warehouse is not unique
+	ErrWarehouseNotUnique = errors.New("repository: warehouse not unique")
+	// ErrWarehouseInUse is an error that will be returned when a warehouse to delete still holds products
+	ErrWarehouseInUse = errors.New("repository: warehouse in use")
+)
+
+// RepositoryWarehouses is an interface that represents a warehouse repository
+// - every method stops when ctx is done, returning context.DeadlineExceeded when its deadline expires
+type RepositoryWarehouses interface {
+	// GetOne returns a warehouse by id
+	GetOne(ctx context.Context, id int) (w Warehouse, err error)
+	// GetAll returns every warehouse, ordered by id
+	GetAll(ctx context.Context) (w []Warehouse, err error)
+	// Store stores a warehouse
+	Store(ctx context.Context, w *Warehouse) (err error)
+	// Update updates a warehouse
+	Update(ctx context.Context, w *Warehouse) (err error)
+	// Delete deletes a warehouse, it fails with ErrWarehouseInUse while products, even in the trash, are stored in it
+	Delete(ctx context.Context, id int) (err error)
+}
@@ -0,0 +1,70 @@
+package filter
+
+// Type is the type of a field that can be filtered
+type Type int
+
+const (
+	// String is a text field, it supports equality and the contains (~) operator
+	String Type = iota
+	// Number is a numeric field, it supports equality and ordering
+	Number
+	// Date is a date field (YYYY-MM-DD), it supports equality and ordering
+	Date
+	// Bool is a boolean field, it supports equality
+	Bool
+)
+
+// Operator is a comparison operator
+type Operator string
+
+const (
+	// OpEq is the equal operator
+	OpEq Operator = "="
+	// OpNe is the not equal operator
+	OpNe Operator = "!="
+	// OpLt is the less than operator
+	OpLt Operator = "<"
+	// OpLe is the less than or equal operator
+	OpLe Operator = "<="
+	// OpGt is the greater than operator
+	OpGt Operator = ">"
+	// OpGe is the greater than or equal operator
+	OpGe Operator = ">="
+	// OpContains is the contains operator, it matches a substring of a text field
+	OpContains Operator = "~"
+)
+
+// Expr is a node of a filter expression
+type Expr interface {
+	expr()
+}
+
+// And is a node that matches when both sides match
+type And struct {
+	Left  Expr
+	Right Expr
+}
+
+// Or is a node that matches when any side matches
+type Or struct {
+	Left  Expr
+	Right Expr
+}
+
+// Not is a node that matches when its expression does not match
+type Not struct {
+	Expr Expr
+}
+
+// Comparison is a node that compares a field against a value
+// - Value is a string, float64, time.Time or bool according to the type of the field
+type Comparison struct {
+	Field string
+	Op    Operator
+	Value any
+}
+
+func (And) expr()        {}
+func (Or) expr()         {}
+func (Not) expr()        {}
+func (Comparison) expr() {}
@@ -0,0 +1,358 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// maxLength is the maximum length of an expression
+	maxLength = 1024
+	// maxDepth is the maximum nesting of an expression
+	maxDepth = 32
+)
+
+// SyntaxError is an error returned when an expression is not valid
+type SyntaxError struct {
+	// Column is the 1-based position of the expression where the error was found
+	Column int
+	// Message describes the error
+	Message string
+}
+
+// Error returns the message of the error with the column where it was found
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at column %d: %s", e.Column, e.Message)
+}
+
+// Parse parses the expression s into an AST validated against fields
+// - fields maps the name of each field that can be filtered to its type
+//
+// grammar:
+//
+//	expr       = and { "or" and }
+//	and        = unary { "and" unary }
+//	unary      = "not" unary | primary
+//	primary    = "(" expr ")" | comparison
+//	comparison = field operator value
+//	operator   = "=" | "!=" | "<" | "<=" | ">" | ">=" | "~"
+//	value      = number | date | "string" | true | false
+func Parse(s string, fields map[string]Type) (e Expr, err error) {
+	if len(s) > maxLength {
+		err = &SyntaxError{Column: maxLength + 1, Message: fmt.Sprintf("expression longer than %d characters", maxLength)}
+		return
+	}
+
+	// tokenize
+	tokens, err := lex(s)
+	if err != nil {
+		return
+	}
+
+	// parse
+	p := &parser{tokens: tokens, fields: fields}
+	e, err = p.parseOr(0)
+	if err != nil {
+		return
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		err = &SyntaxError{Column: t.column, Message: fmt.Sprintf("unexpected %s", t)}
+		return
+	}
+
+	return
+}
+
+// parser is a recursive descent parser over a list of tokens
+type parser struct {
+	// tokens is the list of tokens of the expression, ending with tokenEOF
+	tokens []token
+	// pos is the index of the current token
+	pos int
+	// fields maps the names of the fields to their types
+	fields map[string]Type
+}
+
+// peek returns the current token
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next returns the current token and advances to the next one
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parseOr parses: and { "or" and }
+func (p *parser) parseOr(depth int) (e Expr, err error) {
+	if depth > maxDepth {
+		err = &SyntaxError{Column: p.peek().column, Message: "expression nested too deeply"}
+		return
+	}
+	e, err = p.parseAnd(depth)
+	if err != nil {
+		return
+	}
+	for p.peek().isKeyword("or") {
+		p.next()
+		var right Expr
+		right, err = p.parseAnd(depth)
+		if err != nil {
+			return
+		}
+		e = Or{Left: e, Right: right}
+	}
+	return
+}
+
+// parseAnd parses: unary { "and" unary }
+func (p *parser) parseAnd(depth int) (e Expr, err error) {
+	e, err = p.parseUnary(depth)
+	if err != nil {
+		return
+	}
+	for p.peek().isKeyword("and") {
+		p.next()
+		var right Expr
+		right, err = p.parseUnary(depth)
+		if err != nil {
+			return
+		}
+		e = And{Left: e, Right: right}
+	}
+	return
+}
+
+// parseUnary parses: "not" unary | primary
+func (p *parser) parseUnary(depth int) (e Expr, err error) {
+	if p.peek().isKeyword("not") {
+		p.next()
+		if depth+1 > maxDepth {
+			err = &SyntaxError{Column: p.peek().column, Message: "expression nested too deeply"}
+			return
+		}
+		var inner Expr
+		inner, err = p.parseUnary(depth + 1)
+		if err != nil {
+			return
+		}
+		e = Not{Expr: inner}
+		return
+	}
+	return p.parsePrimary(depth)
+}
+
+// parsePrimary parses: "(" expr ")" | comparison
+func (p *parser) parsePrimary(depth int) (e Expr, err error) {
+	t := p.peek()
+	if t.kind == tokenLParen {
+		p.next()
+		e, err = p.parseOr(depth + 1)
+		if err != nil {
+			return
+		}
+		if t := p.next(); t.kind != tokenRParen {
+			err = &SyntaxError{Column: t.column, Message: fmt.Sprintf("expected ) but found %s", t)}
+		}
+		return
+	}
+	return p.parseComparison()
+}
+
+// parseComparison parses: field operator value
+func (p *parser) parseComparison() (e Expr, err error) {
+	// field
+	f := p.next()
+	if f.kind != tokenIdent || f.isKeyword("and", "or", "not", "true", "false") {
+		err = &SyntaxError{Column: f.column, Message: fmt.Sprintf("expected field but found %s", f)}
+		return
+	}
+	typ, ok := p.fields[f.text]
+	if !ok {
+		err = &SyntaxError{Column: f.column, Message: fmt.Sprintf("unknown field %q", f.text)}
+		return
+	}
+
+	// operator
+	o := p.next()
+	if o.kind != tokenOperator {
+		err = &SyntaxError{Column: o.column, Message: fmt.Sprintf("expected operator but found %s", o)}
+		return
+	}
+	op := Operator(o.text)
+	switch {
+	case op == OpContains && typ != String:
+		err = &SyntaxError{Column: o.column, Message: fmt.Sprintf("operator ~ is not supported by field %q", f.text)}
+		return
+	case op != OpEq && op != OpNe && typ == Bool:
+		err = &SyntaxError{Column: o.column, Message: fmt.Sprintf("operator %s is not supported by field %q", op, f.text)}
+		return
+	}
+
+	// value
+	v := p.next()
+	value, err := v.value(typ)
+	if err != nil {
+		return
+	}
+	e = Comparison{Field: f.text, Op: op, Value: value}
+	return
+}
+
+// tokenKind is the kind of a token
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenOperator
+	tokenString
+	tokenNumber
+	tokenLParen
+	tokenRParen
+)
+
+// token is a lexical unit of an expression
+type token struct {
+	kind tokenKind
+	// text is the text of the token, unquoted for strings
+	text string
+	// column is the 1-based position of the token
+	column int
+}
+
+// String returns a description of the token for error messages
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// isKeyword reports whether the token is an identifier matching any of the keywords, case insensitive
+func (t token) isKeyword(keywords ...string) bool {
+	if t.kind != tokenIdent {
+		return false
+	}
+	for _, k := range keywords {
+		if strings.EqualFold(t.text, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// value converts a literal token into a value of type typ
+func (t token) value(typ Type) (v any, err error) {
+	mismatch := func(expected string) error {
+		return &SyntaxError{Column: t.column, Message: fmt.Sprintf("expected %s but found %s", expected, t)}
+	}
+	switch typ {
+	case String:
+		if t.kind != tokenString {
+			return nil, mismatch("string")
+		}
+		v = t.text
+	case Number:
+		if t.kind != tokenNumber {
+			return nil, mismatch("number")
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, mismatch("number")
+		}
+		v = f
+	case Date:
+		if t.kind != tokenNumber {
+			return nil, mismatch("date")
+		}
+		d, err := time.Parse(time.DateOnly, t.text)
+		if err != nil {
+			return nil, mismatch("date")
+		}
+		v = d
+	case Bool:
+		if !t.isKeyword("true", "false") {
+			return nil, mismatch("true or false")
+		}
+		v = strings.EqualFold(t.text, "true")
+	}
+	return
+}
+
+// lex splits s into tokens
+func lex(s string) (tokens []token, err error) {
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		column := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", column: column})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", column: column})
+			i++
+		case r == '=' || r == '~':
+			tokens = append(tokens, token{kind: tokenOperator, text: string(r), column: column})
+			i++
+		case r == '!' || r == '<' || r == '>':
+			op := string(r)
+			if i+1 < len(rs) && rs[i+1] == '=' {
+				op += "="
+			}
+			if op == "!" {
+				err = &SyntaxError{Column: column, Message: "expected != but found \"!\""}
+				return
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, column: column})
+			i += len(op)
+		case r == '"':
+			// string literal, \" and \\ are escapes
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(rs) && rs[j] != '"'; j++ {
+				if rs[j] == '\\' && j+1 < len(rs) {
+					j++
+				}
+				b.WriteRune(rs[j])
+			}
+			if j >= len(rs) {
+				err = &SyntaxError{Column: column, Message: "unterminated string"}
+				return
+			}
+			tokens = append(tokens, token{kind: tokenString, text: b.String(), column: column})
+			i = j + 1
+		case r == '-' || r == '.' || unicode.IsDigit(r):
+			// number or date literal
+			j := i + 1
+			for ; j < len(rs) && (rs[j] == '-' || rs[j] == '.' || unicode.IsDigit(rs[j])); j++ {
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(rs[i:j]), column: column})
+			i = j
+		case r == '_' || unicode.IsLetter(r):
+			j := i + 1
+			for ; j < len(rs) && (rs[j] == '_' || unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j])); j++ {
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(rs[i:j]), column: column})
+			i = j
+		default:
+			err = &SyntaxError{Column: column, Message: fmt.Sprintf("unexpected character %q", r)}
+			return
+		}
+	}
+	tokens = append(tokens, token{kind: tokenEOF, column: len(rs) + 1})
+	return
+}
@@ -0,0 +1,111 @@
+package filter_test
+
+import (
+	"app/platform/filter"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Parse function
+func TestParse(t *testing.T) {
+	fields := map[string]filter.Type{
+		"name":         filter.String,
+		"price":        filter.Number,
+		"expiration":   filter.Date,
+		"is_published": filter.Bool,
+	}
+
+	t.Run("success - comparison", func(t *testing.T) {
+		// arrange
+		// ...
+
+		// act
+		e, err := filter.Parse(`price >= 10`, fields)
+
+		// assert
+		expectedExpr := filter.Comparison{Field: "price", Op: filter.OpGe, Value: 10.0}
+		require.NoError(t, err)
+		require.Equal(t, expectedExpr, e)
+	})
+
+	t.Run("success - and binds tighter than or", func(t *testing.T) {
+		// arrange
+		// ...
+
+		// act
+		e, err := filter.Parse(`price >= 10 and expiration < 2025-01-01 or name ~ "milk"`, fields)
+
+		// assert
+		expectedExpr := filter.Or{
+			Left: filter.And{
+				Left:  filter.Comparison{Field: "price", Op: filter.OpGe, Value: 10.0},
+				Right: filter.Comparison{Field: "expiration", Op: filter.OpLt, Value: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			Right: filter.Comparison{Field: "name", Op: filter.OpContains, Value: "milk"},
+		}
+		require.NoError(t, err)
+		require.Equal(t, expectedExpr, e)
+	})
+
+	t.Run("success - parenthesis, not and escaped string", func(t *testing.T) {
+		// arrange
+		// ...
+
+		// act
+		e, err := filter.Parse(`not (is_published = TRUE or name = "say \"hi\"")`, fields)
+
+		// assert
+		expectedExpr := filter.Not{Expr: filter.Or{
+			Left:  filter.Comparison{Field: "is_published", Op: filter.OpEq, Value: true},
+			Right: filter.Comparison{Field: "name", Op: filter.OpEq, Value: `say "hi"`},
+		}}
+		require.NoError(t, err)
+		require.Equal(t, expectedExpr, e)
+	})
+
+	t.Run("error - syntax", func(t *testing.T) {
+		cases := []struct {
+			input   string
+			message string
+		}{
+			{`price >=`, `syntax error at column 9: expected number but found end of expression`},
+			{`price >= 10 and`, `syntax error at column 16: expected field but found end of expression`},
+			{`(price > 1`, `syntax error at column 11: expected ) but found end of expression`},
+			{`price 10`, `syntax error at column 7: expected operator but found "10"`},
+			{`price > 1 price`, `syntax error at column 11: unexpected "price"`},
+			{`name = "milk`, `syntax error at column 8: unterminated string`},
+			{`name = milk`, `syntax error at column 8: expected string but found "milk"`},
+			{`price # 1`, `syntax error at column 7: unexpected character '#'`},
+		}
+		for _, c := range cases {
+			// act
+			_, err := filter.Parse(c.input, fields)
+
+			// assert
+			var se *filter.SyntaxError
+			require.ErrorAs(t, err, &se, c.input)
+			require.EqualError(t, err, c.message, c.input)
+		}
+	})
+
+	t.Run("error - validation", func(t *testing.T) {
+		cases := []struct {
+			input   string
+			message string
+		}{
+			{`color = "red"`, `syntax error at column 1: unknown field "color"`},
+			{`price ~ "1"`, `syntax error at column 7: operator ~ is not supported by field "price"`},
+			{`is_published > true`, `syntax error at column 14: operator > is not supported by field "is_published"`},
+			{`expiration < 2025-13-01`, `syntax error at column 14: expected date but found "2025-13-01"`},
+		}
+		for _, c := range cases {
+			// act
+			_, err := filter.Parse(c.input, fields)
+
+			// assert
+			require.EqualError(t, err, c.message, c.input)
+		}
+	})
+}
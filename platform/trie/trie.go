@@ -0,0 +1,233 @@
+package trie
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// New returns a new instance of Trie holding up to maxValues values, 0 means no limit
+func New(maxValues int) *Trie {
+	return &Trie{
+		maxValues: maxValues,
+		root:      newNode(),
+		values:    make(map[int]string),
+	}
+}
+
+// Trie is a case insensitive prefix tree of values identified by id, safe for concurrent use
+// - it can be used as a partial cache: a prefix marked complete holds every value starting with it,
+// so lookups of that prefix (or longer ones) do not need to reach the source of the values
+// - a value beyond maxValues resets the trie, values and complete marks alike, so it stays bounded and its
+// marks stay true
+type Trie struct {
+	// maxValues is the maximum number of values held, 0 means no limit
+	maxValues int
+	// mu guards the fields below
+	mu sync.RWMutex
+	// root is the node of the empty prefix
+	root *node
+	// values maps each id to its value
+	values map[int]string
+}
+
+// node is a node of the trie
+type node struct {
+	// children maps the next rune of the key to the child node
+	children map[rune]*node
+	// ids is the set of ids whose value ends at the node
+	ids map[int]struct{}
+	// complete reports whether every value starting with the prefix of the node is in the trie
+	complete bool
+}
+
+// newNode returns a new empty node
+func newNode() *node {
+	return &node{
+		children: make(map[rune]*node),
+		ids:      make(map[int]struct{}),
+	}
+}
+
+// Set sets the value of an id, replacing the previous one
+func (t *Trie) Set(id int, value string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.delete(id)
+	if t.maxValues > 0 && len(t.values) >= t.maxValues {
+		t.reset()
+	}
+	t.set(id, value)
+}
+
+// Fill sets the values of ids, all of them starting with prefix, and when complete records that they are
+// every value starting with it
+// - values and mark are set under a single lock, so no reset in between can leave the mark wrong
+// - the trie is reset first if the values do not fit along with the ones held, values that do not fit
+// on their own are not set
+func (t *Trie) Fill(prefix string, values map[int]string, complete bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.maxValues > 0 && len(values) > t.maxValues {
+		return
+	}
+	for id := range values {
+		t.delete(id)
+	}
+	if t.maxValues > 0 && len(t.values)+len(values) > t.maxValues {
+		t.reset()
+	}
+	for id, value := range values {
+		t.set(id, value)
+	}
+	if complete {
+		t.markComplete(prefix)
+	}
+}
+
+// set adds the value of an id not in the trie
+func (t *Trie) set(id int, value string) {
+	n := t.root
+	for _, r := range strings.ToLower(value) {
+		child, ok := n.children[r]
+		if !ok {
+			child = newNode()
+			n.children[r] = child
+		}
+		n = child
+	}
+	n.ids[id] = struct{}{}
+	t.values[id] = value
+}
+
+// Delete removes the value of an id
+func (t *Trie) Delete(id int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.delete(id)
+}
+
+// delete removes the value of an id, pruning the nodes left empty
+func (t *Trie) delete(id int) {
+	value, ok := t.values[id]
+	if !ok {
+		return
+	}
+	delete(t.values, id)
+
+	// walk down recording the path
+	key := []rune(strings.ToLower(value))
+	path := []*node{t.root}
+	for _, r := range key {
+		path = append(path, path[len(path)-1].children[r])
+	}
+	delete(path[len(path)-1].ids, id)
+
+	// prune from the leaf up
+	for i := len(path) - 1; i > 0; i-- {
+		n := path[i]
+		if len(n.ids) > 0 || len(n.children) > 0 || n.complete {
+			break
+		}
+		delete(path[i-1].children, key[i-1])
+	}
+}
+
+// MarkComplete records that every value starting with prefix is in the trie
+func (t *Trie) MarkComplete(prefix string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.markComplete(prefix)
+}
+
+// markComplete marks the node of prefix complete, creating the nodes missing
+func (t *Trie) markComplete(prefix string) {
+	n := t.root
+	for _, r := range strings.ToLower(prefix) {
+		child, ok := n.children[r]
+		if !ok {
+			child = newNode()
+			n.children[r] = child
+		}
+		n = child
+	}
+	n.complete = true
+}
+
+// Complete reports whether every value starting with prefix is known to be in the trie
+func (t *Trie) Complete(prefix string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n := t.root
+	if n.complete {
+		return true
+	}
+	for _, r := range strings.ToLower(prefix) {
+		child, ok := n.children[r]
+		if !ok {
+			return false
+		}
+		n = child
+		if n.complete {
+			return true
+		}
+	}
+	return false
+}
+
+// Find returns up to limit distinct values starting with prefix, in byte order of the values
+// - the byte order of UTF-8 is the one of a binary collation, such as utf8mb4_bin in MySQL
+func (t *Trie) Find(prefix string, limit int) (values []string) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	n := t.root
+	for _, r := range strings.ToLower(prefix) {
+		child, ok := n.children[r]
+		if !ok {
+			return
+		}
+		n = child
+	}
+	// - the keys are lowercased, so the order of the tree is not the one of the values: every value is sorted
+	seen := make(map[string]bool)
+	t.collect(n, seen, &values)
+	sort.Strings(values)
+	if len(values) > limit {
+		values = values[:limit]
+	}
+	return
+}
+
+// collect appends to values the distinct values under n not seen yet
+func (t *Trie) collect(n *node, seen map[string]bool, values *[]string) {
+	for id := range n.ids {
+		v := t.values[id]
+		if !seen[v] {
+			seen[v] = true
+			*values = append(*values, v)
+		}
+	}
+	for _, child := range n.children {
+		t.collect(child, seen, values)
+	}
+}
+
+// Reset removes every value and every complete mark
+func (t *Trie) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.reset()
+}
+
+// reset removes every value and every complete mark
+func (t *Trie) reset() {
+	t.root = newNode()
+	t.values = make(map[int]string)
+}
@@ -0,0 +1,180 @@
+package trie_test
+
+import (
+	"app/platform/trie"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Trie
+func TestTrie(t *testing.T) {
+	t.Run("find - case insensitive, distinct and ordered", func(t *testing.T) {
+		// arrange
+		tr := trie.New(0)
+		tr.Set(1, "Milk")
+		tr.Set(2, "milkshake")
+		tr.Set(3, "Milk")
+		tr.Set(4, "Mint")
+		tr.Set(5, "bread")
+
+		// act
+		values := tr.Find("MIL", 10)
+
+		// assert
+		expectedValues := []string{"Milk", "milkshake"}
+		require.Equal(t, expectedValues, values)
+	})
+
+	t.Run("find - limit", func(t *testing.T) {
+		// arrange
+		tr := trie.New(0)
+		tr.Set(1, "ab")
+		tr.Set(2, "abc")
+		tr.Set(3, "abd")
+
+		// act
+		values := tr.Find("a", 2)
+
+		// assert
+		expectedValues := []string{"ab", "abc"}
+		require.Equal(t, expectedValues, values)
+	})
+
+	t.Run("set - replaces the previous value of the id", func(t *testing.T) {
+		// arrange
+		tr := trie.New(0)
+		tr.Set(1, "milk")
+
+		// act
+		tr.Set(1, "water")
+
+		// assert
+		require.Empty(t, tr.Find("m", 10))
+		require.Equal(t, []string{"water"}, tr.Find("w", 10))
+	})
+
+	t.Run("delete - removes the value of the id", func(t *testing.T) {
+		// arrange
+		tr := trie.New(0)
+		tr.Set(1, "milk")
+		tr.Set(2, "milk")
+
+		// act
+		tr.Delete(1)
+		tr.Delete(3)
+
+		// assert
+		require.Equal(t, []string{"milk"}, tr.Find("milk", 10))
+		tr.Delete(2)
+		require.Empty(t, tr.Find("", 10))
+	})
+
+	t.Run("complete - marks are inherited by longer prefixes and survive deletes", func(t *testing.T) {
+		// arrange
+		tr := trie.New(0)
+		tr.Set(1, "milk")
+
+		// act
+		tr.MarkComplete("mi")
+		tr.Delete(1)
+
+		// assert
+		require.True(t, tr.Complete("mi"))
+		require.True(t, tr.Complete("MILK"))
+		require.False(t, tr.Complete("m"))
+		require.False(t, tr.Complete("b"))
+	})
+
+	t.Run("reset - removes values and marks", func(t *testing.T) {
+		// arrange
+		tr := trie.New(0)
+		tr.Set(1, "milk")
+		tr.MarkComplete("")
+
+		// act
+		tr.Reset()
+
+		// assert
+		require.False(t, tr.Complete("milk"))
+		require.Empty(t, tr.Find("", 10))
+	})
+
+	t.Run("find - byte order of the values, as a binary collation", func(t *testing.T) {
+		// arrange
+		tr := trie.New(0)
+		tr.Set(1, "milkshake")
+		tr.Set(2, "Mint")
+		tr.Set(3, "Milk")
+		tr.Set(4, "MILK")
+
+		// act
+		values := tr.Find("mi", 10)
+
+		// assert
+		expectedValues := []string{"MILK", "Milk", "Mint", "milkshake"}
+		require.Equal(t, expectedValues, values)
+	})
+
+	t.Run("set - resets the trie beyond the maximum number of values", func(t *testing.T) {
+		// arrange
+		tr := trie.New(2)
+		tr.Set(1, "ab")
+		tr.Set(2, "ac")
+		tr.MarkComplete("a")
+
+		// act
+		tr.Set(2, "ad")
+		replaced := tr.Find("a", 10)
+		tr.Set(3, "ae")
+
+		// assert
+		require.Equal(t, []string{"ab", "ad"}, replaced)
+		require.Equal(t, []string{"ae"}, tr.Find("a", 10))
+		require.False(t, tr.Complete("a"))
+	})
+
+	t.Run("fill - sets the values and marks the prefix complete", func(t *testing.T) {
+		// arrange
+		tr := trie.New(0)
+
+		// act
+		tr.Fill("mi", map[int]string{1: "Milk", 2: "mint"}, true)
+		tr.Fill("b", map[int]string{3: "bread"}, false)
+
+		// assert
+		require.Equal(t, []string{"Milk", "mint"}, tr.Find("mi", 10))
+		require.True(t, tr.Complete("mil"))
+		require.Equal(t, []string{"bread"}, tr.Find("b", 10))
+		require.False(t, tr.Complete("b"))
+	})
+
+	t.Run("fill - resets the trie before values that do not fit, keeping every one of them", func(t *testing.T) {
+		// arrange
+		tr := trie.New(3)
+		tr.Set(1, "bread")
+		tr.Set(2, "butter")
+
+		// act
+		tr.Fill("mi", map[int]string{3: "milk", 4: "mint"}, true)
+
+		// assert
+		require.Empty(t, tr.Find("b", 10))
+		require.Equal(t, []string{"milk", "mint"}, tr.Find("mi", 10))
+		require.True(t, tr.Complete("mi"))
+	})
+
+	t.Run("fill - values beyond the maximum are not set nor marked", func(t *testing.T) {
+		// arrange
+		tr := trie.New(1)
+		tr.Set(1, "bread")
+
+		// act
+		tr.Fill("mi", map[int]string{2: "milk", 3: "mint"}, true)
+
+		// assert
+		require.Equal(t, []string{"bread"}, tr.Find("b", 10))
+		require.Empty(t, tr.Find("mi", 10))
+		require.False(t, tr.Complete("mi"))
+	})
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"app/platform/web/response"
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// contextKey is the type of the keys of the values stored by the package in a context
+type contextKey int
+
+const (
+	// adminKey is the key of the admin role of the caller
+	adminKey contextKey = iota
+	// userKey is the key of the name of the caller
+	userKey
+)
+
+// Admin is the name of the caller with the admin role when it does not tell its name
+const Admin = "admin"
+
+// MaxUserLength is the maximum number of characters of the name of a caller
+const MaxUserLength = 255
+
+// secretEqual reports whether a secret sent by the caller matches the configured one, an empty one matches nothing
+func secretEqual(sent, configured string) bool {
+	return configured != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(configured)) == 1
+}
+
+// Middleware returns a middleware that identifies the caller of a request from its headers
+// - a bearer token equal to adminToken grants the admin role, an empty adminToken grants it to nobody
+// - the X-User header names the caller only when the request is trusted: it comes from the gateway, carrying
+// gatewaySecret in the X-Gateway-Secret header, or from an admin; otherwise the caller is unknown
+// - an X-User header longer than MaxUserLength characters is rejected
+func Middleware(adminToken, gatewaySecret string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			admin := ok && secretEqual(token, adminToken)
+			trusted := admin || secretEqual(r.Header.Get("X-Gateway-Secret"), gatewaySecret)
+			var user string
+			if trusted {
+				user = strings.TrimSpace(r.Header.Get("X-User"))
+				if utf8.RuneCountInString(user) > MaxUserLength {
+					response.Errorf(w, http.StatusBadRequest, "invalid X-User header, must be at most %d characters", MaxUserLength)
+					return
+				}
+			}
+			if user == "" && admin {
+				user = Admin
+			}
+
+			ctx := context.WithValue(r.Context(), adminKey, admin)
+			ctx = context.WithValue(ctx, userKey, user)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// User returns the name of the caller of the request bound to ctx, empty if it is unknown
+func User(ctx context.Context) string {
+	user, _ := ctx.Value(userKey).(string)
+	return user
+}
+
+// IsAdmin reports whether the caller of the request bound to ctx has the admin role
+func IsAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminKey).(bool)
+	return admin
+}
+
+// RequireAdmin is a middleware that rejects the requests of callers without the admin role
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsAdmin(r.Context()) {
+			response.Error(w, http.StatusForbidden, "admin role required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
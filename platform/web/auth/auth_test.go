@@ -0,0 +1,186 @@
+package auth_test
+
+import (
+	"app/platform/web/auth"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Middleware function
+func TestMiddleware(t *testing.T) {
+	// isAdmin serves a request through the middleware and returns the admin role seen by the handler
+	isAdmin := func(adminToken string, header http.Header) (admin bool) {
+		mw := auth.Middleware(adminToken, "")
+		hd := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			admin = auth.IsAdmin(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header = header
+		hd.ServeHTTP(httptest.NewRecorder(), req)
+		return
+	}
+
+	t.Run("admin - matching token", func(t *testing.T) {
+		// act
+		admin := isAdmin("secret", http.Header{"Authorization": []string{"Bearer secret"}})
+
+		// assert
+		require.True(t, admin)
+	})
+
+	t.Run("not admin - wrong token", func(t *testing.T) {
+		// act
+		admin := isAdmin("secret", http.Header{"Authorization": []string{"Bearer other"}})
+
+		// assert
+		require.False(t, admin)
+	})
+
+	t.Run("not admin - missing header", func(t *testing.T) {
+		// act
+		admin := isAdmin("secret", http.Header{})
+
+		// assert
+		require.False(t, admin)
+	})
+
+	t.Run("not admin - no admin token configured", func(t *testing.T) {
+		// act
+		admin := isAdmin("", http.Header{"Authorization": []string{"Bearer "}})
+
+		// assert
+		require.False(t, admin)
+	})
+}
+
+// Tests for RequireAdmin function
+func TestRequireAdmin(t *testing.T) {
+	t.Run("200 - admin", func(t *testing.T) {
+		// arrange
+		hd := auth.Middleware("secret", "")(auth.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+
+		// act
+		rr := httptest.NewRecorder()
+		hd.ServeHTTP(rr, req)
+
+		// assert
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("403 - not admin", func(t *testing.T) {
+		// arrange
+		hd := auth.Middleware("secret", "")(auth.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		// act
+		rr := httptest.NewRecorder()
+		hd.ServeHTTP(rr, req)
+
+		// assert
+		expectedBody := `{"status":"Forbidden","message":"admin role required"}`
+		require.Equal(t, http.StatusForbidden, rr.Code)
+		require.Equal(t, expectedBody, rr.Body.String())
+	})
+}
+
+// Tests for User function
+func TestUser(t *testing.T) {
+	// user serves a request through the middleware and returns the user seen by the handler
+	user := func(header http.Header) (user string) {
+		mw := auth.Middleware("secret", "gateway")
+		hd := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user = auth.User(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header = header
+		hd.ServeHTTP(httptest.NewRecorder(), req)
+		return
+	}
+
+	t.Run("named caller", func(t *testing.T) {
+		// act
+		u := user(http.Header{"X-User": []string{"jane"}, "Authorization": []string{"Bearer secret"}})
+
+		// assert
+		require.Equal(t, "jane", u)
+	})
+
+	t.Run("named by the gateway", func(t *testing.T) {
+		// act
+		u := user(http.Header{"X-User": []string{"jane"}, "X-Gateway-Secret": []string{"gateway"}})
+
+		// assert
+		require.Equal(t, "jane", u)
+	})
+
+	t.Run("untrusted name - no secret", func(t *testing.T) {
+		// act
+		u := user(http.Header{"X-User": []string{"jane"}})
+
+		// assert
+		require.Equal(t, "", u)
+	})
+
+	t.Run("untrusted name - wrong gateway secret", func(t *testing.T) {
+		// act
+		u := user(http.Header{"X-User": []string{"jane"}, "X-Gateway-Secret": []string{"other"}})
+
+		// assert
+		require.Equal(t, "", u)
+	})
+
+	t.Run("unnamed admin", func(t *testing.T) {
+		// act
+		u := user(http.Header{"Authorization": []string{"Bearer secret"}})
+
+		// assert
+		require.Equal(t, auth.Admin, u)
+	})
+
+	t.Run("unknown caller", func(t *testing.T) {
+		// act
+		u := user(http.Header{})
+
+		// assert
+		require.Equal(t, "", u)
+	})
+
+	t.Run("400 - name too long", func(t *testing.T) {
+		// arrange
+		called := false
+		hd := auth.Middleware("secret", "gateway")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Gateway-Secret", "gateway")
+		req.Header.Set("X-User", strings.Repeat("é", auth.MaxUserLength+1))
+
+		// act
+		rr := httptest.NewRecorder()
+		hd.ServeHTTP(rr, req)
+
+		// assert
+		expectedBody := `{"status":"Bad Request","message":"invalid X-User header, must be at most 255 characters"}`
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Equal(t, expectedBody, rr.Body.String())
+		require.False(t, called)
+	})
+
+	t.Run("longest name", func(t *testing.T) {
+		// act
+		u := user(http.Header{"X-User": []string{strings.Repeat("é", auth.MaxUserLength)}, "X-Gateway-Secret": []string{"gateway"}})
+
+		// assert
+		require.Equal(t, strings.Repeat("é", auth.MaxUserLength), u)
+	})
+}
@@ -0,0 +1,84 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrCursorInvalid is used when the cursor token is malformed or its signature does not match.
+	ErrCursorInvalid = errors.New("cursor invalid")
+)
+
+// NewSigner returns a new instance of Signer
+func NewSigner(key []byte) *Signer {
+	return &Signer{
+		key: key,
+	}
+}
+
+// Signer encodes and decodes opaque cursor tokens signed with HMAC-SHA256
+type Signer struct {
+	// key is the secret used to sign the tokens
+	key []byte
+}
+
+// Encode serializes v into an url-safe signed token
+func (s *Signer) Encode(v any) (token string, err error) {
+	// marshal payload
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	// sign payload
+	enc := base64.RawURLEncoding
+	token = enc.EncodeToString(payload) + "." + enc.EncodeToString(s.sign(payload))
+	return
+}
+
+// Decode verifies the signature of token and deserializes its payload into ptr
+func (s *Signer) Decode(token string, ptr any) (err error) {
+	// split token
+	p, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		err = ErrCursorInvalid
+		return
+	}
+	enc := base64.RawURLEncoding
+	payload, err := enc.DecodeString(p)
+	if err != nil {
+		err = ErrCursorInvalid
+		return
+	}
+	mac, err := enc.DecodeString(sig)
+	if err != nil {
+		err = ErrCursorInvalid
+		return
+	}
+
+	// verify signature
+	if !hmac.Equal(mac, s.sign(payload)) {
+		err = ErrCursorInvalid
+		return
+	}
+
+	// unmarshal payload
+	if err = json.Unmarshal(payload, ptr); err != nil {
+		err = ErrCursorInvalid
+		return
+	}
+
+	return
+}
+
+// sign returns the HMAC-SHA256 of payload
+func (s *Signer) sign(payload []byte) []byte {
+	h := hmac.New(sha256.New, s.key)
+	h.Write(payload)
+	return h.Sum(nil)
+}
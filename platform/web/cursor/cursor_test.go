@@ -0,0 +1,79 @@
+package cursor_test
+
+import (
+	"app/platform/web/cursor"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Signer
+func TestSigner(t *testing.T) {
+	type payload struct {
+		ID int `json:"id"`
+	}
+
+	t.Run("success - encode and decode", func(t *testing.T) {
+		// arrange
+		s := cursor.NewSigner([]byte("secret"))
+
+		// act
+		token, err := s.Encode(payload{ID: 42})
+		require.NoError(t, err)
+		var p payload
+		err = s.Decode(token, &p)
+
+		// assert
+		expectedPayload := payload{ID: 42}
+		require.NoError(t, err)
+		require.Equal(t, expectedPayload, p)
+	})
+
+	t.Run("error - signed with another key", func(t *testing.T) {
+		// arrange
+		s1 := cursor.NewSigner([]byte("secret"))
+		s2 := cursor.NewSigner([]byte("another"))
+
+		// act
+		token, err := s1.Encode(payload{ID: 42})
+		require.NoError(t, err)
+		var p payload
+		err = s2.Decode(token, &p)
+
+		// assert
+		require.ErrorIs(t, err, cursor.ErrCursorInvalid)
+		require.Equal(t, payload{}, p)
+	})
+
+	t.Run("error - tampered payload", func(t *testing.T) {
+		// arrange
+		s := cursor.NewSigner([]byte("secret"))
+		token, err := s.Encode(payload{ID: 42})
+		require.NoError(t, err)
+		forged, err := s.Encode(payload{ID: 7})
+		require.NoError(t, err)
+
+		// act
+		_, sig, _ := strings.Cut(token, ".")
+		body, _, _ := strings.Cut(forged, ".")
+		var p payload
+		err = s.Decode(body+"."+sig, &p)
+
+		// assert
+		require.ErrorIs(t, err, cursor.ErrCursorInvalid)
+	})
+
+	t.Run("error - malformed token", func(t *testing.T) {
+		// arrange
+		s := cursor.NewSigner([]byte("secret"))
+
+		// act
+		var p payload
+		err := s.Decode("not-a-token", &p)
+
+		// assert
+		require.ErrorIs(t, err, cursor.ErrCursorInvalid)
+		require.EqualError(t, err, "cursor invalid")
+	})
+}
@@ -0,0 +1,84 @@
+package request
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+var (
+	// ErrRequestContentTypeNotCSV is used when the request content type is not text/csv.
+	ErrRequestContentTypeNotCSV = errors.New("request content type is not text/csv")
+	// ErrRequestCSVInvalid is used when the request csv is invalid.
+	ErrRequestCSVInvalid = errors.New("request csv invalid")
+)
+
+// CSV returns a reader of the csv request body, the first row is read as the header
+func CSV(r *http.Request) (rd *CSVReader, err error) {
+	// check content type
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "text/csv" {
+		err = ErrRequestContentTypeNotCSV
+		return
+	}
+
+	// read header
+	cr := csv.NewReader(r.Body)
+	header, err := cr.Read()
+	if err != nil {
+		if err == io.EOF {
+			err = fmt.Errorf("%w. missing header", ErrRequestCSVInvalid)
+			return
+		}
+		err = fmt.Errorf("%w. %w", ErrRequestCSVInvalid, err)
+		return
+	}
+
+	rd = &CSVReader{
+		r:      cr,
+		header: header,
+	}
+	return
+}
+
+// CSVReader is a reader of csv records mapped by the names of the header row
+type CSVReader struct {
+	// r is the underlying csv reader
+	r *csv.Reader
+	// header is the list of column names
+	header []string
+}
+
+// Header returns the column names of the header row
+func (c *CSVReader) Header() []string {
+	return c.header
+}
+
+// Read returns the next record mapped by column name and the line where it starts
+// - io.EOF is returned when there are no more records
+// - a record with a wrong number of fields returns an error wrapping csv.ErrFieldCount,
+// the reader can go on with the next record
+func (c *CSVReader) Read() (record map[string]string, line int, err error) {
+	fields, err := c.r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return
+		}
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			line = pe.StartLine
+		}
+		err = fmt.Errorf("%w. %w", ErrRequestCSVInvalid, err)
+		return
+	}
+
+	line, _ = c.r.FieldPos(0)
+	record = make(map[string]string, len(c.header))
+	for i, name := range c.header {
+		record[name] = fields[i]
+	}
+	return
+}
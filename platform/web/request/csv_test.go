@@ -0,0 +1,114 @@
+package request_test
+
+import (
+	"app/platform/web/request"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for CSV function
+func TestRequestCSV(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		// arrange
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"text/csv; charset=utf-8"}},
+			Body:   io.NopCloser(strings.NewReader("name,price\nmilk,1.5\n\"bread, white\",2\n")),
+		}
+
+		// act
+		rd, err := request.CSV(&inputRequest)
+		require.NoError(t, err)
+		record1, line1, err1 := rd.Read()
+		record2, line2, err2 := rd.Read()
+		_, _, err3 := rd.Read()
+
+		// assert
+		require.Equal(t, []string{"name", "price"}, rd.Header())
+		require.NoError(t, err1)
+		require.Equal(t, map[string]string{"name": "milk", "price": "1.5"}, record1)
+		require.Equal(t, 2, line1)
+		require.NoError(t, err2)
+		require.Equal(t, map[string]string{"name": "bread, white", "price": "2"}, record2)
+		require.Equal(t, 3, line2)
+		require.ErrorIs(t, err3, io.EOF)
+	})
+
+	t.Run("error - content-type", func(t *testing.T) {
+		// arrange
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+			Body:   io.NopCloser(strings.NewReader("name\nmilk\n")),
+		}
+
+		// act
+		rd, err := request.CSV(&inputRequest)
+
+		// assert
+		require.Nil(t, rd)
+		require.ErrorIs(t, err, request.ErrRequestContentTypeNotCSV)
+		require.EqualError(t, err, "request content type is not text/csv")
+	})
+
+	t.Run("error - missing header", func(t *testing.T) {
+		// arrange
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"text/csv"}},
+			Body:   io.NopCloser(strings.NewReader("")),
+		}
+
+		// act
+		rd, err := request.CSV(&inputRequest)
+
+		// assert
+		require.Nil(t, rd)
+		require.ErrorIs(t, err, request.ErrRequestCSVInvalid)
+		require.EqualError(t, err, "request csv invalid. missing header")
+	})
+
+	t.Run("error - wrong number of fields, reading goes on", func(t *testing.T) {
+		// arrange
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"text/csv"}},
+			Body:   io.NopCloser(strings.NewReader("name,price\nmilk\nbread,2\n")),
+		}
+
+		// act
+		rd, err := request.CSV(&inputRequest)
+		require.NoError(t, err)
+		record1, line1, err1 := rd.Read()
+		record2, line2, err2 := rd.Read()
+
+		// assert
+		require.ErrorIs(t, err1, request.ErrRequestCSVInvalid)
+		require.ErrorIs(t, err1, csv.ErrFieldCount)
+		require.Nil(t, record1)
+		require.Equal(t, 2, line1)
+		require.NoError(t, err2)
+		require.Equal(t, map[string]string{"name": "bread", "price": "2"}, record2)
+		require.Equal(t, 3, line2)
+	})
+
+	t.Run("error - unterminated quote in the first record", func(t *testing.T) {
+		// arrange
+		inputRequest := http.Request{
+			Header: http.Header{"Content-Type": []string{"text/csv"}},
+			Body:   io.NopCloser(strings.NewReader("name,price\n\"milk,1\n")),
+		}
+
+		// act
+		rd, err := request.CSV(&inputRequest)
+		require.NoError(t, err)
+		record, line, err := rd.Read()
+
+		// assert
+		require.ErrorIs(t, err, request.ErrRequestCSVInvalid)
+		require.ErrorIs(t, err, csv.ErrQuote)
+		require.Nil(t, record)
+		require.Equal(t, 2, line)
+	})
+}
@@ -0,0 +1,39 @@
+package response
+
+import "net/http"
+
+// NewStream writes the header of a response whose body is sent in chunks and returns the writer of the body
+func NewStream(w http.ResponseWriter, code int, contentType string) *Stream {
+	// set header
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	// set status code
+	w.WriteHeader(code)
+
+	f, _ := w.(http.Flusher)
+	return &Stream{
+		w: w,
+		f: f,
+	}
+}
+
+// Stream is a writer of a response body that can be flushed to the client while it is written
+type Stream struct {
+	// w is the response writer
+	w http.ResponseWriter
+	// f is the flusher of the response writer, nil if it does not support flushing
+	f http.Flusher
+}
+
+// Write writes p to the body
+func (s *Stream) Write(p []byte) (n int, err error) {
+	return s.w.Write(p)
+}
+
+// Flush sends the body written so far to the client
+func (s *Stream) Flush() {
+	if s.f != nil {
+		s.f.Flush()
+	}
+}
@@ -0,0 +1,53 @@
+package response_test
+
+import (
+	"app/platform/web/response"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Tests for Stream
+func TestStream(t *testing.T) {
+	t.Run("200 - chunks flushed", func(t *testing.T) {
+		// arrange
+		// ...
+
+		// act
+		rr := httptest.NewRecorder()
+		s := response.NewStream(rr, http.StatusOK, "application/x-ndjson")
+		_, err1 := s.Write([]byte("{\"id\":1}\n"))
+		s.Flush()
+		flushed := rr.Flushed
+		_, err2 := s.Write([]byte("{\"id\":2}\n"))
+
+		// assert
+		expectedHeader := http.Header{"Content-Type": []string{"application/x-ndjson"}, "X-Content-Type-Options": []string{"nosniff"}}
+		expectedCode := http.StatusOK
+		expectedBody := "{\"id\":1}\n{\"id\":2}\n"
+		require.NoError(t, err1)
+		require.NoError(t, err2)
+		require.True(t, flushed)
+		require.Equal(t, expectedHeader, rr.Header())
+		require.Equal(t, expectedCode, rr.Code)
+		require.Equal(t, expectedBody, rr.Body.String())
+	})
+
+	t.Run("200 - writer without flush support", func(t *testing.T) {
+		// arrange
+		rr := httptest.NewRecorder()
+		w := struct{ http.ResponseWriter }{rr}
+
+		// act
+		s := response.NewStream(w, http.StatusOK, "text/csv")
+		_, err := s.Write([]byte("id\n"))
+		s.Flush()
+
+		// assert
+		require.NoError(t, err)
+		require.False(t, rr.Flushed)
+		require.Equal(t, "id\n", rr.Body.String())
+	})
+}